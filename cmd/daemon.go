@@ -1,42 +1,76 @@
 package cmd
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
-	"strconv"
 
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/pidfile"
 	"go.uber.org/zap"
 )
 
 var (
-	pid     = -1
-	pidFile string
+	pid       = -1
+	pidFile   string
+	tokenFile string
+)
+
+// States of the server recorded in the pid file.
+const (
+	daemonRunning = "running"
+	daemonStopped = "stopped"
+	// daemonStale is a pid file left by a server that is gone.
+	daemonStale = "stale"
 )
 
 func initDaemon(logger *zap.Logger) {
 	exe, err := os.Executable()
 	if err != nil {
 		logger.Error("failed to get the path name for the executable", zap.Error(err))
-		fmt.Println("Failed to get the path name for the executable. See log.json for details.")
-		os.Exit(1)
+		fatal(exitFailed, "Failed to get the path name for the executable. See log.json for details.")
 	}
 
 	exeDir := filepath.Dir(exe)
 	_ = os.MkdirAll(filepath.Join(exeDir, "daemon"), 0700)
 	pidFile = filepath.Join(exeDir, "daemon", "pid")
+	tokenFile = filepath.Join(exeDir, "daemon", "token")
 	if _, err = os.Stat(pidFile); err == nil {
-		pidData, err := os.ReadFile(pidFile)
-		if err != nil {
-			logger.Error("failed to read the pid file", zap.Error(err))
-			fmt.Println("Failed to read pid file. See log.json for details.")
-			os.Exit(1)
-		}
-		pid, err = strconv.Atoi(string(pidData))
+		pid, err = pidfile.Read(pidFile)
 		if err != nil {
-			logger.Error("failed to parse the pid file", zap.Error(err))
-			fmt.Println("Failed to parse pid file. See log.json for details.")
-			os.Exit(1)
+			logger.Warn("failed to read the pid file", zap.Error(err))
+			removeDaemonFiles(logger)
+			pid = -1
 		}
 	}
 }
+
+// daemonState returns the state of the server recorded in the pid file.
+func daemonState(logger *zap.Logger) string {
+	if pid == -1 {
+		return daemonStopped
+	}
+	locked, err := pidfile.Locked(pidFile)
+	if err != nil {
+		logger.Warn("failed to check the pid file lock", zap.Error(err))
+		locked = true
+	}
+	if !locked || !processAlive(pid) {
+		return daemonStale
+	}
+	return daemonRunning
+}
+
+func removeStalePidFile(logger *zap.Logger) {
+	logger.Warn("remove stale pid file", zap.Int("pid", pid))
+	removeDaemonFiles(logger)
+	pid = -1
+}
+
+// removeDaemonFiles removes the pid file and the token file. It reports whether the pid file is removed.
+func removeDaemonFiles(logger *zap.Logger) bool {
+	_ = os.Remove(tokenFile)
+	if err := os.Remove(pidFile); err != nil && !os.IsNotExist(err) {
+		logger.Error("failed to remove pid file", zap.Error(err))
+		return false
+	}
+	return true
+}
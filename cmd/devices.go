@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/spf13/cobra"
+)
+
+var devicesAdbPath string
+
+var devicesCmd = &cobra.Command{
+	Use:   "devices",
+	Short: "List the connected adb devices.",
+	Run:   devicesRun,
+}
+
+type devicesResult struct {
+	Devices []logic.AdbDevice `json:"devices"`
+}
+
+func devicesRun(_ *cobra.Command, _ []string) {
+	deviceLogic := logic.NewDeviceLogic(config.New())
+	devices := deviceLogic.FindAdbDevices(devicesAdbPath)
+	printResult(devicesResult{Devices: devices})
+	if len(devices) == 0 {
+		textln("No adb device found.")
+		return
+	}
+
+	for i, device := range devices {
+		if i > 0 {
+			textln()
+		}
+		textln("Serial:", device.Serial)
+		textln("Name:", device.Name)
+		textln("Adb Path:", device.AdbPath)
+		textln("Screencap:", strings.Join(device.ScreencapMethods, ", "))
+		textln("Input:", strings.Join(device.InputMethods, ", "))
+		textln("Config:", device.Config)
+	}
+}
+
+func init() {
+	devicesCmd.PersistentFlags().StringVar(&devicesAdbPath, "adb", "", "Specify the adb path to search with")
+	rootCmd.AddCommand(devicesCmd)
+}
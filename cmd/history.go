@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyTaskerID string
+	historyTrigger  string
+	historyStatus   string
+	historySince    time.Duration
+	historyLimit    int
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show the history of runs.",
+	Run:   historyRun,
+}
+
+type historyResult struct {
+	Runs []history.Run `json:"runs"`
+}
+
+func historyRun(_ *cobra.Command, _ []string) {
+	query := history.Query{
+		TaskerID: historyTaskerID,
+		Trigger:  history.Trigger(historyTrigger),
+		Status:   historyStatus,
+		Limit:    historyLimit,
+	}
+	if historySince > 0 {
+		query.Since = time.Now().Add(-historySince)
+	}
+
+	runLogic := logic.NewRunLogic()
+	runs, err := runLogic.GetRuns(query)
+	if err != nil {
+		fatal(exitFailed, fmt.Sprintf("Failed to read run history: %v", err))
+	}
+	if runs == nil {
+		runs = []history.Run{}
+	}
+	printResult(historyResult{Runs: runs})
+	if len(runs) == 0 {
+		textln("No run found.")
+		return
+	}
+
+	for i, run := range runs {
+		if i > 0 {
+			textln()
+		}
+		resumed := ""
+		if run.Resumed {
+			resumed = " (resumed)"
+		}
+		if run.AdHoc {
+			resumed = " (ad hoc)"
+		}
+		textf("%s  %-11s  %-9s  %s%s\n",
+			run.StartedAt.Local().Format(time.DateTime),
+			run.Status,
+			run.Trigger,
+			run.EndedAt.Sub(run.StartedAt).Round(time.Second),
+			resumed,
+		)
+		textf("  Run: %s  Tasker: %s\n", run.ID, run.TaskerID)
+		if run.Error != "" {
+			textf("  Error: %s\n", run.Error)
+		}
+		for _, task := range run.Tasks {
+			line := fmt.Sprintf("  #%d %-11s %s (%s)",
+				task.Index,
+				task.Status,
+				task.Entry,
+				task.EndedAt.Sub(task.StartedAt).Round(time.Second),
+			)
+			if task.Error != "" {
+				line += ": " + task.Error
+			}
+			textln(line)
+		}
+	}
+}
+
+func init() {
+	historyCmd.PersistentFlags().StringVar(&historyTaskerID, "id", "", "Only show the runs of the tasker with this id")
+	historyCmd.PersistentFlags().StringVar(&historyTrigger, "trigger", "", "Only show the runs started by this trigger (cli, websocket, scheduler)")
+	historyCmd.PersistentFlags().StringVar(&historyStatus, "status", "", "Only show the runs with this status (running, success, failed, interrupted)")
+	historyCmd.PersistentFlags().DurationVar(&historySince, "since", 0, "Only show the runs started within this duration, e.g. 12h")
+	historyCmd.PersistentFlags().IntVar(&historyLimit, "limit", 20, "Maximum number of runs to show, 0 for all")
+	rootCmd.AddCommand(historyCmd)
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"github.com/dongwlin/elf-aid-magic/internal/logquery"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsSince    string
+	logsUntil    string
+	logsLevel    string
+	logsTaskerID string
+	logsGrep     string
+	logsLimit    int
+	logsFollow   bool
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Search the logs, including the rotated log files.",
+	Run:   logsRun,
+}
+
+type logsResult struct {
+	Logs []json.RawMessage `json:"logs"`
+}
+
+func logsRun(_ *cobra.Command, _ []string) {
+	query := logquery.Query{
+		Level:    logsLevel,
+		TaskerID: logsTaskerID,
+		Contains: logsGrep,
+		Limit:    logsLimit,
+	}
+	if _, ok := logger.LookupLevel(logsLevel); logsLevel != "" && !ok {
+		fatal(exitFailed, fmt.Sprintf("Invalid level %q, must be one of debug, info, warn, error and fatal.", logsLevel))
+	}
+	var err error
+	if query.Since, err = parseLogsTime(logsSince); err != nil {
+		fatal(exitFailed, fmt.Sprintf("Invalid since: %v", err))
+	}
+	if query.Until, err = parseLogsTime(logsUntil); err != nil {
+		fatal(exitFailed, fmt.Sprintf("Invalid until: %v", err))
+	}
+	if logsFollow && !query.Until.IsZero() {
+		fatal(exitFailed, "--until can not be used with --follow")
+	}
+
+	path, err := logger.DefaultFile()
+	if err != nil {
+		fatal(exitFailed, fmt.Sprintf("Failed to get the path of the log file: %v", err))
+	}
+	entries, pos, err := logquery.SearchPosition(path, query)
+	if err != nil {
+		fatal(exitFailed, fmt.Sprintf("Failed to search logs: %v", err))
+	}
+
+	if !logsFollow {
+		if jsonOutput() {
+			result := logsResult{Logs: make([]json.RawMessage, 0, len(entries))}
+			for _, entry := range entries {
+				result.Logs = append(result.Logs, entry.Raw)
+			}
+			printResult(result)
+			return
+		}
+		if len(entries) == 0 {
+			fmt.Println("No log found.")
+			return
+		}
+		for _, entry := range entries {
+			printLogEntry(entry)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		printLogEntry(entry)
+	}
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	query.Limit = 0
+	if err := logquery.Follow(ctx, path, query, pos, printLogEntry); err != nil {
+		fatal(exitFailed, fmt.Sprintf("Failed to follow logs: %v", err))
+	}
+}
+
+// parseLogsTime parses a duration before now, e.g. 2h, or an RFC 3339 time.
+func parseLogsTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// logTextKeys are the keys of a log printed before its other fields in text output.
+var logTextKeys = []string{"time", "level", "msg", logger.TaskerIDKey, "caller", "stacktrace"}
+
+// printLogEntry prints the raw line in json output, or a readable line in text output.
+func printLogEntry(entry logquery.Entry) {
+	if jsonOutput() {
+		fmt.Println(string(entry.Raw))
+		return
+	}
+
+	var fields map[string]interface{}
+	_ = json.Unmarshal(entry.Raw, &fields)
+	for _, key := range logTextKeys {
+		delete(fields, key)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s  %-5s  ", entry.Time.Local().Format("2006-01-02 15:04:05.000"), strings.ToUpper(entry.Level.String()))
+	if entry.TaskerID != "" {
+		fmt.Fprintf(&b, "[%s] ", entry.TaskerID)
+	}
+	b.WriteString(entry.Message)
+	if len(fields) > 0 {
+		data, _ := json.Marshal(fields)
+		b.WriteString("  ")
+		b.Write(data)
+	}
+	fmt.Println(b.String())
+}
+
+func init() {
+	logsCmd.Flags().StringVar(&logsSince, "since", "", "Only show the logs after this time, a duration before now (e.g. 2h) or an RFC 3339 time")
+	logsCmd.Flags().StringVar(&logsUntil, "until", "", "Only show the logs before this time, a duration before now or an RFC 3339 time")
+	logsCmd.Flags().StringVar(&logsLevel, "level", "", "Only show the logs at this level or above (debug, info, warn, error, fatal)")
+	logsCmd.Flags().StringVar(&logsTaskerID, "id", "", "Only show the logs of the tasker with this id")
+	logsCmd.Flags().StringVar(&logsGrep, "grep", "", "Only show the logs whose message contains this text, ignoring case")
+	logsCmd.Flags().IntVar(&logsLimit, "limit", 100, "Maximum number of logs to show, the most recent ones are kept, 0 for all")
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing the new logs until interrupted")
+	rootCmd.AddCommand(logsCmd)
+}
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Output formats of the global --output flag.
+const (
+	outputText = "text"
+	outputJSON = "json"
+)
+
+// Exit codes of the commands.
+const (
+	exitOK            = 0
+	exitFailed        = 1
+	exitInitFailed    = 2
+	exitConnectFailed = 3
+	exitTaskFailed    = 4
+	exitInterrupted   = 130
+)
+
+var output string
+
+func jsonOutput() bool {
+	return output == outputJSON
+}
+
+// textln prints a line in text output. In json output only the result of the command is printed.
+func textln(a ...any) {
+	if !jsonOutput() {
+		fmt.Println(a...)
+	}
+}
+
+func textf(format string, a ...any) {
+	if !jsonOutput() {
+		fmt.Printf(format, a...)
+	}
+}
+
+// printResult prints the result of the command in json output.
+func printResult(result any) {
+	if !jsonOutput() {
+		return
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to serialize result:", err)
+		return
+	}
+	fmt.Println(string(data))
+}
+
+type errorResult struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// fatal prints msg, as an error result in json output, and exits with code.
+func fatal(code int, msg string) {
+	if jsonOutput() {
+		printResult(errorResult{
+			Status:  "failed",
+			Message: msg,
+		})
+	} else {
+		fmt.Println(msg)
+	}
+	os.Exit(code)
+}
+
+func validateOutput(_ *cobra.Command, _ []string) error {
+	if output != outputText && output != outputJSON {
+		return fmt.Errorf("invalid output %q, must be %s or %s", output, outputText, outputJSON)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputText, "Output format, text or json")
+	rootCmd.PersistentPreRunE = validateOutput
+}
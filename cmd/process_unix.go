@@ -0,0 +1,19 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// terminateProcess asks the process to exit, which the server handles like an interrupt.
+func terminateProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var restartCmd = &cobra.Command{
+	Use:   "restart",
+	Short: "Stop eam server if it is running, then start it",
+	Run:   restartRun,
+}
+
+type restartResult struct {
+	Stop  stopResult   `json:"stop"`
+	Start *startResult `json:"start,omitempty"`
+}
+
+func restartRun(cmd *cobra.Command, args []string) {
+	conf := config.New()
+
+	l := logger.New(conf)
+	defer l.Sync()
+
+	initDaemon(l)
+	var result restartResult
+	stopped, ok := stopServer(conf, l)
+	result.Stop = stopped
+	if ok {
+		started, startOK := startServer(conf, l)
+		result.Start = &started
+		ok = startOK
+	}
+	printResult(result)
+	if !ok {
+		l.Sync()
+		os.Exit(exitFailed)
+	}
+}
+
+func init() {
+	restartCmd.Flags().DurationVar(&stopTimeout, "timeout", stopTimeoutDefault, "Time to wait for the server to exit before killing it")
+	restartCmd.Flags().DurationVar(&startWait, "wait", startWaitDefault, "Time to wait for the server to be ready")
+	rootCmd.AddCommand(restartCmd)
+}
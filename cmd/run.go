@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/signal"
@@ -9,6 +10,7 @@ import (
 	"syscall"
 
 	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/history"
 	"github.com/dongwlin/elf-aid-magic/internal/logger"
 	"github.com/dongwlin/elf-aid-magic/internal/operator"
 	"github.com/spf13/cobra"
@@ -16,53 +18,68 @@ import (
 )
 
 var (
-	id   string
-	name string
+	ids    []string
+	names  []string
+	all    bool
+	resume bool
+	entry  string
+	param  string
 )
 
 var runCmd = &cobra.Command{
 	Use:   "run",
 	Short: "Run elf-aid-magic.",
-	Run:   runRun,
+	Long: `Run elf-aid-magic.
+
+Exit codes:
+  0    all tasks succeeded
+  1    invalid arguments or config
+  2    failed to init a tasker
+  3    failed to connect a device
+  4    some tasks failed
+  130  interrupted`,
+	Run: runRun,
 }
 
 func runRun(_ *cobra.Command, _ []string) {
-	stopped := false
+	if entry == "" && param != "" {
+		fatal(exitFailed, "--param requires --entry")
+	}
+	if entry != "" && resume {
+		fatal(exitFailed, "--entry can not be used with --resume")
+	}
+	entryParam := map[string]interface{}{}
+	if param != "" {
+		if err := json.Unmarshal([]byte(param), &entryParam); err != nil {
+			fatal(exitFailed, fmt.Sprintf("Invalid param, it must be a JSON object: %v", err))
+		}
+	}
+
 	conf := config.New()
 
 	l := logger.New(conf)
 	defer l.Sync()
 
-	if id == "" {
-		if len(conf.Taskers) == 0 {
-			fmt.Println("taskers is empty")
-			os.Exit(1)
-		}
-		id = conf.Taskers[0].ID
-	}
-	if name != "" {
-		id = getTaskerIDByName(conf, name)
-	}
-	if id == "" {
-		fmt.Println("tasker name not exists")
-		os.Exit(1)
+	taskers, err := selectTaskers(conf)
+	if err != nil {
+		fatal(exitFailed, err.Error())
 	}
 
 	l.Info("START")
 
-	fmt.Println("Link Start!")
-
-	o := operator.New(conf, l, id)
-	defer o.Destroy()
-
-	initOperator(o)
+	textln("Link Start!")
 
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	var wg sync.WaitGroup
-	wg.Add(1)
+	var (
+		wg      sync.WaitGroup
+		mutex   sync.Mutex
+		started []*operator.Operator
+		stopped bool
+	)
 
 	go func() {
 		sig := <-sigs
@@ -70,67 +87,268 @@ func runRun(_ *cobra.Command, _ []string) {
 			"received interrupt signal to stop",
 			zap.String("signal", sig.String()),
 		)
+		mutex.Lock()
 		stopped = true
-		o.Stop().Wait()
-		cancel()
-	}()
+		running := make([]*operator.Operator, len(started))
+		copy(running, started)
+		mutex.Unlock()
 
-	go func() {
-		defer wg.Done()
-		if !o.Run(ctx) && !stopped {
-			fmt.Println("Failed to run tasks.")
+		cancel()
+		// StopRun holds the lock of the operator and skips one whose run is over, which may already be destroyed.
+		for _, o := range running {
+			o.StopRun()
 		}
 	}()
 
-	fmt.Println("Running...")
+	results := make([]taskerRunResult, len(taskers))
+	for i, tasker := range taskers {
+		wg.Add(1)
+		go func(i int, tasker *config.TaskerConfig) {
+			defer wg.Done()
+			p := newTaskerPrinter(tasker, len(taskers) > 1)
+			result := &results[i]
+			result.TaskerID = tasker.ID
+			result.Name = tasker.Name
 
-	wg.Wait()
+			o := operator.New(conf, l, tasker.ID)
+			defer o.Destroy()
 
-	if stopped {
-		fmt.Println("Interrupt")
-	} else {
-		fmt.Println("Completed")
+			if result.Status, result.Error = initOperator(o); result.Status != "" {
+				p.Println(result.Error)
+				return
+			}
+
+			mutex.Lock()
+			if stopped {
+				mutex.Unlock()
+				result.Status = runStatusInterrupted
+				return
+			}
+			started = append(started, o)
+			mutex.Unlock()
+			// Forget the operator before it is destroyed, so that the signal handler does not keep stopping it.
+			defer func() {
+				mutex.Lock()
+				defer mutex.Unlock()
+				for j, s := range started {
+					if s == o {
+						started = append(started[:j], started[j+1:]...)
+						break
+					}
+				}
+			}()
+
+			p.Println("Running...")
+			run := o.Run
+			if resume {
+				run = o.Resume
+			}
+			if entry != "" {
+				run = func(ctx context.Context, trigger history.Trigger) bool {
+					return o.RunEntry(ctx, trigger, entry, entryParam)
+				}
+			}
+			ok := run(ctx, history.TriggerCLI)
+			result.Run = o.LastRun()
+			result.Status = runStatus(ctx, ok, result.Run)
+			switch result.Status {
+			case runStatusSuccess:
+				p.Println("Completed")
+			case runStatusInterrupted:
+				p.Println("Interrupt")
+			case runStatusTaskFailed:
+				p.Println("Completed with failed tasks")
+			default:
+				p.Println("Failed to run tasks.")
+			}
+			if result.Run != nil {
+				result.Error = result.Run.Error
+			}
+		}(i, tasker)
 	}
+
+	wg.Wait()
 	l.Info("END")
+
+	status, code := combineRunResults(results)
+	printResult(runResult{
+		Status:   status,
+		ExitCode: code,
+		Taskers:  results,
+	})
+	if code != exitOK {
+		l.Sync()
+		os.Exit(code)
+	}
+}
+
+// Statuses of a tasker in the result of `eam run`.
+const (
+	runStatusSuccess       = "success"
+	runStatusInitFailed    = "init_failed"
+	runStatusConnectFailed = "connect_failed"
+	runStatusTaskFailed    = "task_failed"
+	runStatusInterrupted   = "interrupted"
+)
+
+// runStatusExitCodes is ordered by precedence, the exit code of a run is the one of the first status any tasker ended with.
+var runStatusExitCodes = []struct {
+	status string
+	code   int
+}{
+	{runStatusInterrupted, exitInterrupted},
+	{runStatusInitFailed, exitInitFailed},
+	{runStatusConnectFailed, exitConnectFailed},
+	{runStatusTaskFailed, exitTaskFailed},
+}
+
+type taskerRunResult struct {
+	TaskerID string       `json:"tasker_id"`
+	Name     string       `json:"name"`
+	Status   string       `json:"status"`
+	Error    string       `json:"error,omitempty"`
+	Run      *history.Run `json:"run,omitempty"`
 }
 
-func getTaskerIDByName(conf *config.Config, name string) string {
+type runResult struct {
+	Status   string            `json:"status"`
+	ExitCode int               `json:"exit_code"`
+	Taskers  []taskerRunResult `json:"taskers"`
+}
+
+// runStatus returns the status of a tasker from the result of its run.
+// A run only fails without being interrupted before any task if the tasker is not initialized,
+// or if no controller stays connected.
+func runStatus(ctx context.Context, ok bool, run *history.Run) string {
+	switch {
+	case run == nil:
+		if ctx.Err() != nil {
+			return runStatusInterrupted
+		}
+		return runStatusInitFailed
+	case run.Status == history.StatusInterrupted:
+		return runStatusInterrupted
+	case !ok:
+		return runStatusConnectFailed
+	case run.Status != history.StatusSuccess:
+		return runStatusTaskFailed
+	default:
+		return runStatusSuccess
+	}
+}
+
+func combineRunResults(results []taskerRunResult) (string, int) {
+	for _, sc := range runStatusExitCodes {
+		for _, result := range results {
+			if result.Status == sc.status {
+				return sc.status, sc.code
+			}
+		}
+	}
+	return runStatusSuccess, exitOK
+}
+
+// selectTaskers returns the taskers given by --all, --id and --name, or the first tasker if none is given.
+func selectTaskers(conf *config.Config) ([]*config.TaskerConfig, error) {
+	if len(conf.Taskers) == 0 {
+		return nil, fmt.Errorf("taskers is empty")
+	}
+	if all {
+		return conf.Taskers, nil
+	}
+	if len(ids) == 0 && len(names) == 0 {
+		return conf.Taskers[:1], nil
+	}
+
+	var taskers []*config.TaskerConfig
+	seen := make(map[string]bool)
+	add := func(tasker *config.TaskerConfig) {
+		if !seen[tasker.ID] {
+			seen[tasker.ID] = true
+			taskers = append(taskers, tasker)
+		}
+	}
+	for _, id := range ids {
+		tasker := getTaskerByID(conf, id)
+		if tasker == nil {
+			return nil, fmt.Errorf("tasker id %s not exists", id)
+		}
+		add(tasker)
+	}
+	for _, name := range names {
+		tasker := getTaskerByName(conf, name)
+		if tasker == nil {
+			return nil, fmt.Errorf("tasker name %s not exists", name)
+		}
+		add(tasker)
+	}
+	return taskers, nil
+}
+
+func getTaskerByID(conf *config.Config, id string) *config.TaskerConfig {
+	for _, tasker := range conf.Taskers {
+		if tasker.ID == id {
+			return tasker
+		}
+	}
+	return nil
+}
+
+func getTaskerByName(conf *config.Config, name string) *config.TaskerConfig {
 	for _, tasker := range conf.Taskers {
 		if tasker.Name == name {
-			return tasker.ID
+			return tasker
 		}
 	}
-	return ""
+	return nil
+}
+
+// taskerPrinter prints console output, prefixed with the tasker when several taskers run at once.
+type taskerPrinter struct {
+	prefix string
+}
+
+func newTaskerPrinter(tasker *config.TaskerConfig, prefixed bool) *taskerPrinter {
+	if !prefixed {
+		return &taskerPrinter{}
+	}
+	label := tasker.Name
+	if label == "" {
+		label = tasker.ID
+	}
+	return &taskerPrinter{prefix: "[" + label + "] "}
+}
+
+func (p *taskerPrinter) Println(a ...any) {
+	textf("%s%s", p.prefix, fmt.Sprintln(a...))
 }
 
-func initOperator(o *operator.Operator) {
+// initOperator initializes and connects o. On failure, it returns the run status and the error message.
+func initOperator(o *operator.Operator) (string, string) {
 	if !o.InitTasker() {
-		fmt.Println("Failed to init takser.")
-		o.Destroy()
-		os.Exit(1)
+		return runStatusInitFailed, "Failed to init takser."
 	}
 
 	if !o.InitResource() {
-		fmt.Println("Failed to init resource.")
-		o.Destroy()
-		os.Exit(1)
+		return runStatusInitFailed, "Failed to init resource."
 	}
 
 	if !o.InitController() {
-		fmt.Println("Failed to init controller.")
-		o.Destroy()
-		os.Exit(1)
+		return runStatusInitFailed, "Failed to init controller."
 	}
 
 	if !o.Connect() {
-		fmt.Println("Failed to connect device.")
-		o.Destroy()
-		os.Exit(1)
+		return runStatusConnectFailed, "Failed to connect device."
 	}
+	return "", ""
 }
 
 func init() {
-	runCmd.PersistentFlags().StringVar(&id, "id", "", "Specify the tasker by id")
-	runCmd.PersistentFlags().StringVar(&name, "name", "", "Specify the tasker by name")
+	runCmd.PersistentFlags().StringArrayVar(&ids, "id", nil, "Specify the tasker by id, can be repeated")
+	runCmd.PersistentFlags().StringArrayVar(&names, "name", nil, "Specify the tasker by name, can be repeated")
+	runCmd.PersistentFlags().BoolVar(&all, "all", false, "Run every tasker in parallel")
+	runCmd.PersistentFlags().BoolVar(&resume, "resume", false, "Resume the last interrupted run from its checkpoint")
+	runCmd.PersistentFlags().StringVar(&entry, "entry", "", "Run only the specified pipeline entry instead of the tasks of the tasker")
+	runCmd.PersistentFlags().StringVar(&param, "param", "", "JSON object used as the param of --entry")
 	rootCmd.AddCommand(runCmd)
 }
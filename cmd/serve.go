@@ -2,15 +2,19 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/dongwlin/elf-aid-magic/internal/config"
 	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"github.com/dongwlin/elf-aid-magic/internal/notify"
 	"github.com/dongwlin/elf-aid-magic/internal/operator"
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/pidfile"
 	"github.com/dongwlin/elf-aid-magic/internal/wire"
 	"github.com/dongwlin/elf-aid-magic/public"
 	"github.com/gofiber/contrib/fiberzap/v2"
@@ -31,12 +35,67 @@ var serveCmd = &cobra.Command{
 func serveRun(cmd *cobra.Command, args []string) {
 	conf := config.New()
 
-	l := logger.New(conf)
+	logLevel := logger.NewLevel(conf)
+	l := logger.NewWithLevel(conf, logLevel)
 	defer l.Sync()
 
+	initDaemon(l)
+	pf, err := pidfile.Acquire(pidFile)
+	if err != nil {
+		l.Error("failed to acquire pid file",
+			zap.Error(err),
+		)
+		if errors.Is(err, pidfile.ErrLocked) {
+			fmt.Println("eam is already running. Try `eam status` for details.")
+		} else {
+			fmt.Println("Failed to acquire pid file. See log.jsonl for details.")
+		}
+		os.Exit(1)
+	}
+	defer pf.Release()
+
+	logStream := logger.NewStream(conf.Log.StreamBuffer)
+	l = logStream.Attach(l, logger.StreamLevel(logger.ParseLevel(conf.Log.StreamLevel), logLevel))
+
 	om := operator.NewManager()
+	for _, tasker := range conf.Taskers {
+		om.AddOperator(operator.New(conf, l, tasker.ID))
+	}
+
+	notifier, err := notify.NewDispatcherFromConfig(conf.Notifiers, l)
+	if err != nil {
+		l.Error("failed to init notifiers",
+			zap.Error(err),
+		)
+		fmt.Println("Failed to init notifiers:", err)
+		os.Exit(1)
+	}
+	om.AddEventListener(func(event operator.Event) {
+		notifier.Dispatch(notify.Event{
+			Name:     event.Name,
+			TaskerID: event.TaskerID,
+			Data:     event.Data,
+			Time:     event.Time,
+		})
+	})
 
-	h := wire.InitHandler(l, om)
+	h := wire.InitHandler(conf, l, om, logStream, logLevel)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	h.Shutdown.SetShutdownFunc(func() {
+		select {
+		case quit <- syscall.SIGTERM:
+		default:
+		}
+	})
+
+	if err := os.WriteFile(tokenFile, []byte(h.Shutdown.Token()), 0600); err != nil {
+		l.Warn("failed to write token file",
+			zap.Error(err),
+		)
+	}
+	defer os.Remove(tokenFile)
 
 	app := fiber.New()
 
@@ -56,16 +115,33 @@ func serveRun(cmd *cobra.Command, args []string) {
 		}
 	}()
 
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt)
 	<-quit
 
 	l.Info("shutdown server ...")
+	h.Shutdown.MarkShuttingDown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+	if err := om.Shutdown(ctx); err != nil {
+		l.Error(
+			"failed to stop operators",
+			zap.Error(err),
+		)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := notifier.Wait(ctx); err != nil {
+		l.Warn(
+			"failed to send pending notifications",
+			zap.Error(err),
+		)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := app.ShutdownWithContext(ctx); err != nil {
-		l.Fatal(
+		l.Error(
 			"server shutdown",
 			zap.Error(err),
 		)
@@ -83,6 +159,8 @@ func initRouter(r fiber.Router, h *wire.Handler) {
 
 	h.Pid.Register(r)
 	h.Ping.Register(r)
+	h.Health.Register(r)
+	h.Metrics.Register(r)
 
 	ws := r.Group("/ws")
 	ws.Use(func(c *fiber.Ctx) error {
@@ -96,6 +174,13 @@ func initRouter(r fiber.Router, h *wire.Handler) {
 
 	api := r.Group("/api")
 	h.Vesrion.Register(api)
+	h.Device.Register(api)
+	h.Run.Register(api)
+	h.Bundle.Register(api)
+	h.Stream.Register(api)
+	h.Input.Register(api)
+	h.Log.Register(api)
+	h.Shutdown.Register(api)
 }
 
 func init() {
@@ -3,13 +3,13 @@ package cmd
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strconv"
 	"time"
 
 	"github.com/dongwlin/elf-aid-magic/internal/config"
@@ -18,6 +18,10 @@ import (
 	"go.uber.org/zap"
 )
 
+const startWaitDefault = 30 * time.Second
+
+var startWait time.Duration
+
 var startCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Silent start eam server",
@@ -26,6 +30,19 @@ var startCmd = &cobra.Command{
 	},
 }
 
+type startResult struct {
+	Status  string `json:"status"`
+	Pid     int    `json:"pid,omitempty"`
+	Port    int    `json:"port"`
+	URL     string `json:"url,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+var (
+	errServerExited   = errors.New("server exited")
+	errServerNotReady = errors.New("server not ready")
+)
+
 func start() {
 	conf := config.New()
 
@@ -33,67 +50,138 @@ func start() {
 	defer l.Sync()
 
 	initDaemon(l)
-	if pid != -1 && isProcessRunning(conf, pid) {
-		l.Info("eam already started",
+	result, ok := startServer(conf, l)
+	printResult(result)
+	if !ok {
+		l.Sync()
+		os.Exit(exitFailed)
+	}
+}
+
+// startServer starts `eam serve` in the background and waits until it is ready.
+func startServer(conf *config.Config, l *zap.Logger) (startResult, bool) {
+	result := startResult{
+		Port: conf.Server.Port,
+	}
+	fail := func(msg string) (startResult, bool) {
+		textln(msg)
+		result.Status = "failed"
+		result.Message = msg
+		return result, false
+	}
+
+	switch daemonState(l) {
+	case daemonRunning:
+		if isProcessRunning(conf, pid) {
+			l.Info("eam already started",
+				zap.Int("pid", pid),
+			)
+			textf("eam already started, pid: %d\n", pid)
+			result.Status = "running"
+			result.Pid = pid
+			result.URL = fmt.Sprintf("http://localhost:%d", conf.Server.Port)
+			return result, true
+		}
+		l.Warn("eam is running, but not responding",
 			zap.Int("pid", pid),
 		)
-		fmt.Printf("eam already started, pid: %d\n", pid)
-		return
+		return fail(fmt.Sprintf("eam is running, but not responding, pid: %d. Try `eam restart`.", pid))
+	case daemonStale:
+		removeStalePidFile(l)
 	}
 	if isServerRunning(conf) {
 		l.Warn("eam may be running, but not the current PID",
 			zap.Int("pid", pid),
 		)
-		fmt.Printf("eam may be running, but not the current PID: %d\n", pid)
-		return
+		return fail(fmt.Sprintf("eam may be running, but not the current PID: %d", pid))
 	}
 
-	serveArgs := os.Args
-	serveArgs[1] = "serve"
-	serve := &exec.Cmd{
-		Path: serveArgs[0],
-		Args: serveArgs,
-		Env:  os.Environ(),
+	exe, err := os.Executable()
+	if err != nil {
+		l.Error("failed to get the path name for the executable",
+			zap.Error(err),
+		)
+		return fail("Failed to get the path name for the executable. See log.jsonl for details.")
 	}
-	stdout, err := os.OpenFile(filepath.Join(filepath.Dir(pidFile), "start.log"), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	stdout, err := openStartLog(conf)
 	if err != nil {
 		l.Error("failed to open start file",
 			zap.Error(err),
 		)
-		fmt.Println("Failed to open the start file. See log.jsonl for details.")
-		os.Exit(1)
+		return fail("Failed to open the start file. See log.jsonl for details.")
 	}
+	defer stdout.Close()
+
+	serve := exec.Command(exe, "serve")
+	// The server logs to log.jsonl, start.log only keeps what it prints before the logger is created.
+	serve.Env = append(os.Environ(), config.EnvPrefix+"_LOG_CONSOLE=false")
 	serve.Stdout = stdout
 	serve.Stderr = stdout
 	if err = serve.Start(); err != nil {
 		l.Error("failed to start children process",
 			zap.Error(err),
 		)
-		fmt.Println("Failed to start children process. See log.jsonl for details.")
-		os.Exit(1)
+		return fail("Failed to start children process. See log.jsonl for details.")
 	}
 
-	// Wait for a short period to allow the server to start
-	time.Sleep(5 * time.Second)
-
-	if !isServerRunning(conf) {
-		l.Error("failed to verify server start")
-		fmt.Println("Failed to verify server start. See log.jsonl for details.")
-		os.Exit(1)
+	if err := waitServerReady(conf, serve, startWait); err != nil {
+		l.Error("failed to verify server start",
+			zap.Int("pid", serve.Process.Pid),
+			zap.Error(err),
+		)
+		if errors.Is(err, errServerNotReady) {
+			_ = serve.Process.Kill()
+			return fail(fmt.Sprintf("Server is not ready in %s. See daemon/start.log and log.jsonl for details.", startWait))
+		}
+		return fail("Server exited during start. See daemon/start.log and log.jsonl for details.")
 	}
 
 	l.Info("success to start",
 		zap.Int("pid", serve.Process.Pid),
 	)
-	fmt.Printf("Success to start, pid: %d\n", serve.Process.Pid)
-	fmt.Printf("Local: http://localhost:%d\n", conf.Server.Port)
+	result.Status = "started"
+	result.Pid = serve.Process.Pid
+	result.URL = fmt.Sprintf("http://localhost:%d", conf.Server.Port)
+	textf("Success to start, pid: %d\n", serve.Process.Pid)
+	textf("Local: %s\n", result.URL)
+	return result, true
+}
 
-	err = os.WriteFile(pidFile, []byte(strconv.Itoa(serve.Process.Pid)), 0666)
+// openStartLog opens the file the server writes its console output to.
+// A header is written through lumberjack first, which rotates the file with the log settings.
+func openStartLog(conf *config.Config) (*os.File, error) {
+	path := filepath.Join(filepath.Dir(pidFile), "start.log")
+	w := logger.NewRotatingWriter(conf, path)
+	_, err := fmt.Fprintf(w, "==== start at %s\n", time.Now().Format(time.RFC3339))
+	w.Close()
 	if err != nil {
-		l.Warn("failed to write pid file",
-			zap.Error(err),
-		)
-		fmt.Println("Failed to record pid, you may not be able to stop the program with `eam stop`. See log.jsonl for details.")
+		return nil, err
+	}
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+}
+
+// waitServerReady polls the server until it answers with the pid of serve, serve exits, or timeout passes.
+func waitServerReady(conf *config.Config, serve *exec.Cmd, timeout time.Duration) error {
+	exited := make(chan error, 1)
+	go func() {
+		exited <- serve.Wait()
+	}()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("%w: %v", errServerExited, err)
+		case <-deadline.C:
+			return errServerNotReady
+		case <-ticker.C:
+			if isProcessRunning(conf, serve.Process.Pid) {
+				return nil
+			}
+		}
 	}
 }
 
@@ -103,7 +191,8 @@ func isProcessRunning(conf *config.Config, pid int) bool {
 		return false
 	}
 	url := fmt.Sprintf("http://localhost:%d/pid/validate", conf.Server.Port)
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(reqBody))
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(reqBody))
 	if err != nil {
 		return false
 	}
@@ -123,7 +212,8 @@ func isProcessRunning(conf *config.Config, pid int) bool {
 }
 
 func isServerRunning(conf *config.Config) bool {
-	resp, err := http.Get(fmt.Sprintf("http://localhost:%d/ping", conf.Server.Port))
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(fmt.Sprintf("http://localhost:%d/ping", conf.Server.Port))
 	if err != nil {
 		return false
 	}
@@ -138,5 +228,6 @@ func isServerRunning(conf *config.Config) bool {
 }
 
 func init() {
+	startCmd.Flags().DurationVar(&startWait, "wait", startWaitDefault, "Time to wait for the server to be ready")
 	rootCmd.AddCommand(startCmd)
 }
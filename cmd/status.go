@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether eam server is running",
+	Long: `Show whether eam server is running.
+
+The status is one of:
+  running       the server is running and responding
+  unresponsive  the server process is running, but does not respond
+  stale         the pid file is left by a server that is gone
+  stopped       the server is not running
+
+Exits with 0 only if the server is running.`,
+	Run: statusRun,
+}
+
+type statusResult struct {
+	Status string `json:"status"`
+	Pid    int    `json:"pid,omitempty"`
+	Port   int    `json:"port"`
+	URL    string `json:"url,omitempty"`
+}
+
+func statusRun(cmd *cobra.Command, args []string) {
+	conf := config.New()
+
+	l := logger.New(conf)
+	defer l.Sync()
+
+	initDaemon(l)
+	result := serverStatus(conf, daemonState(l))
+	switch result.Status {
+	case "running":
+		textf("eam is running, pid: %d\n", result.Pid)
+		textf("Local: %s\n", result.URL)
+	case "unresponsive":
+		textf("eam is running, but not responding, pid: %d\n", result.Pid)
+	case "stale":
+		textf("eam is not running, but the pid file of process %d is left\n", result.Pid)
+	default:
+		textln("eam is not running")
+	}
+	printResult(result)
+	if result.Status != "running" {
+		l.Sync()
+		os.Exit(exitFailed)
+	}
+}
+
+func serverStatus(conf *config.Config, state string) statusResult {
+	result := statusResult{
+		Status: state,
+		Port:   conf.Server.Port,
+	}
+	if state == daemonStopped {
+		return result
+	}
+	result.Pid = pid
+	if state == daemonRunning {
+		if !isProcessRunning(conf, pid) {
+			result.Status = "unresponsive"
+			return result
+		}
+		result.URL = fmt.Sprintf("http://localhost:%d", conf.Server.Port)
+	}
+	return result
+}
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+}
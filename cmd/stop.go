@@ -2,7 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/dongwlin/elf-aid-magic/internal/config"
 	"github.com/dongwlin/elf-aid-magic/internal/logger"
@@ -12,10 +15,21 @@ import (
 
 var stopCmd = &cobra.Command{
 	Use:   "stop",
-	Short: "Stop eam server by daemon/pid file",
+	Short: "Gracefully stop eam server by daemon/pid file",
 	Run:   stopRun,
 }
 
+const stopTimeoutDefault = 30 * time.Second
+
+var stopTimeout time.Duration
+
+type stopResult struct {
+	Status  string `json:"status"`
+	Pid     int    `json:"pid,omitempty"`
+	Method  string `json:"method,omitempty"` // api | signal | kill
+	Message string `json:"message,omitempty"`
+}
+
 func stopRun(cmd *cobra.Command, args []string) {
 	conf := config.New()
 
@@ -23,33 +37,122 @@ func stopRun(cmd *cobra.Command, args []string) {
 	defer l.Sync()
 
 	initDaemon(l)
-	if pid == -1 {
+	result, ok := stopServer(conf, l)
+	printResult(result)
+	if !ok {
+		l.Sync()
+		os.Exit(exitFailed)
+	}
+}
+
+// stopServer asks the server to shut down and waits for it to exit, killing it after stopTimeout.
+func stopServer(conf *config.Config, l *zap.Logger) (stopResult, bool) {
+	result := stopResult{
+		Pid: pid,
+	}
+	fail := func(msg string) (stopResult, bool) {
+		textln(msg)
+		result.Status = "failed"
+		result.Message = msg
+		return result, false
+	}
+
+	switch daemonState(l) {
+	case daemonStopped:
 		l.Warn("seems not have been started yet")
-		fmt.Println("Seems not have been started. Try use `eam start` to start server.")
-		return
+		textln("Seems not have been started. Try use `eam start` to start server.")
+		result.Status = "not_running"
+		result.Pid = 0
+		return result, true
+	case daemonStale:
+		textf("Process %d is not running, remove the stale pid file.\n", pid)
+		removeStalePidFile(l)
+		result.Status = "not_running"
+		return result, true
 	}
 	process, err := os.FindProcess(pid)
 	if err != nil {
 		l.Error("failed to find process", zap.Int("pid", pid), zap.Error(err))
-		fmt.Printf("Failed to find process by pid: %d. See log.json for details.\n", pid)
-		os.Exit(1)
+		return fail(fmt.Sprintf("Failed to find process by pid: %d. See log.json for details.", pid))
 	}
-	err = process.Kill()
-	if err != nil {
-		l.Error("failed tod kill process", zap.Int("pid", pid), zap.Error(err))
-		fmt.Printf("Failed to kill process by pid: %d. See log.json for details.\n", pid)
-		os.Exit(1)
+
+	if err := requestShutdown(conf); err == nil {
+		result.Method = "api"
 	} else {
+		l.Warn("failed to request shutdown", zap.Error(err))
+		if err := terminateProcess(process); err == nil {
+			result.Method = "signal"
+		} else {
+			l.Warn("failed to terminate process", zap.Int("pid", pid), zap.Error(err))
+		}
+	}
+
+	if result.Method != "" {
+		textf("Stopping process by pid: %d ...\n", pid)
+		if !waitProcessExit(pid, stopTimeout) {
+			l.Warn("process did not exit in time", zap.Int("pid", pid), zap.Duration("timeout", stopTimeout))
+			textf("Process did not exit in %s, kill it.\n", stopTimeout)
+			result.Method = ""
+		}
+	}
+	if result.Method == "" {
+		if err := process.Kill(); err != nil {
+			l.Error("failed tod kill process", zap.Int("pid", pid), zap.Error(err))
+			return fail(fmt.Sprintf("Failed to kill process by pid: %d. See log.json for details.", pid))
+		}
+		result.Method = "kill"
 		l.Info("killed process", zap.Int("pid", pid))
-		fmt.Printf("Killed process by pid: %d\n", pid)
+		textf("Killed process by pid: %d\n", pid)
+	} else {
+		l.Info("stopped process", zap.Int("pid", pid), zap.String("method", result.Method))
+		textf("Stopped process by pid: %d\n", pid)
+	}
+	result.Status = "stopped"
+	// A server that exited gracefully has removed its pid file already.
+	if !removeDaemonFiles(l) {
+		textln("Failed to remove pid file. See log.json for details.")
+		result.Message = "Failed to remove pid file."
+	}
+	pid = -1
+	return result, true
+}
+
+// requestShutdown asks the server to shut down through /api/shutdown with the token it wrote in the daemon directory.
+func requestShutdown(conf *config.Config) error {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return err
 	}
-	err = os.Remove(pidFile)
+	url := fmt.Sprintf("http://localhost:%d/api/shutdown", conf.Server.Port)
+	req, err := http.NewRequest(http.MethodPost, url, nil)
 	if err != nil {
-		l.Error("failed to remove pid file", zap.Error(err))
-		fmt.Println("Failed to remove pid file. See log.json for details.")
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+func waitProcessExit(pid int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(200 * time.Millisecond)
 	}
+	return true
 }
 
 func init() {
+	stopCmd.Flags().DurationVar(&stopTimeout, "timeout", stopTimeoutDefault, "Time to wait for the server to exit before killing it")
 	rootCmd.AddCommand(stopCmd)
 }
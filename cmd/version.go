@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/dongwlin/elf-aid-magic/internal/logic"
 	"github.com/spf13/cobra"
 )
@@ -13,12 +11,26 @@ var versionCmd = &cobra.Command{
 	Run:   versionRun,
 }
 
+type versionResult struct {
+	Version             string `json:"version"`
+	GoVersion           string `json:"go_version"`
+	BuildAt             string `json:"build_at"`
+	MaaFrameworkVersion string `json:"maa_framework_version"`
+}
+
 func versionRun(cmd *cobra.Command, args []string) {
 	versionLogic := logic.NewVersionLogic()
-	fmt.Println("Build At:", versionLogic.GetBuildAt())
-	fmt.Println("Go Version:", versionLogic.GetGoVersion())
-	fmt.Println("Version:", versionLogic.GetElfAidMagicVersion())
-	fmt.Println("Maa Framework Version:", versionLogic.GetMaaFrameworkVersion())
+	result := versionResult{
+		Version:             versionLogic.GetElfAidMagicVersion(),
+		GoVersion:           versionLogic.GetGoVersion(),
+		BuildAt:             versionLogic.GetBuildAt(),
+		MaaFrameworkVersion: versionLogic.GetMaaFrameworkVersion(),
+	}
+	textln("Build At:", result.BuildAt)
+	textln("Go Version:", result.GoVersion)
+	textln("Version:", result.Version)
+	textln("Maa Framework Version:", result.MaaFrameworkVersion)
+	printResult(result)
 }
 
 func init() {
@@ -4,66 +4,171 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"reflect"
 
-	"github.com/MaaXYZ/maa-framework-go"
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/winmatch"
 	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/viper"
 )
 
 type Config struct {
-	Server  *ServerConfig   `mapstructure:"server" toml:"server"`
-	Log     *LogConfig      `mapstructure:"log" toml:"log"`
-	AdbPath string          `mapstructure:"adb_path" toml:"adb_path"`
-	Taskers []*TaskerConfig `mapstructure:"taskers" toml:"taskers"`
-}
-
+	ConfigVersion int               `mapstructure:"config_version" toml:"config_version"`
+	Server        *ServerConfig     `mapstructure:"server" toml:"server"`
+	Log           *LogConfig        `mapstructure:"log" toml:"log"`
+	Reconnect     *ReconnectConfig  `mapstructure:"reconnect" toml:"reconnect"`
+	Debug         *DebugConfig      `mapstructure:"debug" toml:"debug"`
+	Stream        *StreamConfig     `mapstructure:"stream" toml:"stream"`
+	AdbPath       string            `mapstructure:"adb_path" toml:"adb_path"`
+	Notifiers     []*NotifierConfig `mapstructure:"notifiers" toml:"notifiers"`
+	Taskers       []*TaskerConfig   `mapstructure:"taskers" toml:"taskers"`
+}
+
+// ServerConfig configures the server. The server has no authentication, so ManualInput lets any client
+// which can reach the port click, swipe and type on the devices.
 type ServerConfig struct {
-	Port int `mapstructure:"port" toml:"port"`
+	Port        int  `mapstructure:"port" toml:"port"`
+	ManualInput bool `mapstructure:"manual_input" toml:"manual_input"`
 }
 
+// LogConfig controls the log sinks: the console, debug/log.jsonl and one file per tasker under debug/taskers.
+// Color only applies when the console is a terminal.
+// The server also sends the logs at StreamLevel or above to WebSocket clients, replaying the last StreamBuffer lines.
 type LogConfig struct {
-	Level      string `mapstructure:"level" toml:"level"`
-	MaxSize    int    `mapstructure:"max_size" toml:"max_size"`
-	MaxBackups int    `mapstructure:"max_backups" toml:"max_backups"`
-	MaxAge     int    `mapstructure:"max_age" toml:"max_age"`
-	Compress   bool   `mapstructure:"compress" toml:"compress"`
+	Level        string `mapstructure:"level" toml:"level"`
+	Console      bool   `mapstructure:"console" toml:"console"`
+	Color        bool   `mapstructure:"color" toml:"color"`
+	File         bool   `mapstructure:"file" toml:"file"`
+	TaskerFiles  bool   `mapstructure:"tasker_files" toml:"tasker_files"`
+	StreamLevel  string `mapstructure:"stream_level" toml:"stream_level"`
+	StreamBuffer int    `mapstructure:"stream_buffer" toml:"stream_buffer"`
+	MaxSize      int    `mapstructure:"max_size" toml:"max_size"`
+	MaxBackups   int    `mapstructure:"max_backups" toml:"max_backups"`
+	MaxAge       int    `mapstructure:"max_age" toml:"max_age"`
+	Compress     bool   `mapstructure:"compress" toml:"compress"`
+}
+
+// ReconnectConfig controls how a disconnected controller is reconnected.
+// The delay starts at InitialDelay seconds, at least 1, and doubles after every failed attempt, up to MaxDelay seconds.
+type ReconnectConfig struct {
+	MaxAttempts  int `mapstructure:"max_attempts" toml:"max_attempts"`
+	InitialDelay int `mapstructure:"initial_delay" toml:"initial_delay"`
+	MaxDelay     int `mapstructure:"max_delay" toml:"max_delay"`
+}
+
+// DebugConfig controls the debug bundles saved when a task fails.
+// Only the newest BundleRetention bundles are kept, 0 keeps every bundle.
+type DebugConfig struct {
+	BundleRetention int `mapstructure:"bundle_retention" toml:"bundle_retention"`
+}
+
+// StreamConfig controls the MJPEG stream of the screen of an operator.
+type StreamConfig struct {
+	FPS     int `mapstructure:"fps" toml:"fps"`
+	Quality int `mapstructure:"quality" toml:"quality"`
+}
+
+// NotifierConfig configures a notifier of the server, which is one of webhook, smtp and exec.
+// Events, RunStatuses and Taskers filter the events sent to the notifier, an empty list matches everything.
+// RunStatuses only applies to run_completed. Timeout is in seconds.
+type NotifierConfig struct {
+	Name        string                `mapstructure:"name" toml:"name"`
+	Type        string                `mapstructure:"type" toml:"type"`
+	Events      []string              `mapstructure:"events" toml:"events"`
+	RunStatuses []string              `mapstructure:"run_statuses" toml:"run_statuses"`
+	Taskers     []string              `mapstructure:"taskers" toml:"taskers"`
+	Timeout     int                   `mapstructure:"timeout" toml:"timeout"`
+	Webhook     WebhookNotifierConfig `mapstructure:"webhook" toml:"webhook"`
+	SMTP        SMTPNotifierConfig    `mapstructure:"smtp" toml:"smtp"`
+	Exec        ExecNotifierConfig    `mapstructure:"exec" toml:"exec"`
+}
+
+// WebhookNotifierConfig posts every event as JSON to URL.
+type WebhookNotifierConfig struct {
+	URL     string            `mapstructure:"url" toml:"url"`
+	Headers map[string]string `mapstructure:"headers" toml:"headers"`
+}
+
+// SMTPNotifierConfig mails every event to To. The connection is upgraded with STARTTLS when the server supports it.
+type SMTPNotifierConfig struct {
+	Host     string   `mapstructure:"host" toml:"host"`
+	Port     int      `mapstructure:"port" toml:"port"`
+	Username string   `mapstructure:"username" toml:"username"`
+	Password string   `mapstructure:"password" toml:"password"`
+	From     string   `mapstructure:"from" toml:"from"`
+	To       []string `mapstructure:"to" toml:"to"`
+}
+
+// ExecNotifierConfig runs Command with Args for every event, passing the event as JSON on stdin.
+type ExecNotifierConfig struct {
+	Command string   `mapstructure:"command" toml:"command"`
+	Args    []string `mapstructure:"args" toml:"args"`
 }
 
 type TaskerConfig struct {
 	ID          string            `mapstructure:"id" toml:"id"`
 	Name        string            `mapstructure:"name" toml:"name"`
 	CtrlType    string            `mapstructure:"ctrl_type" toml:"ctrl_type"`
+	CtrlTypes   []string          `mapstructure:"ctrl_types" toml:"ctrl_types"`
 	Win32Window Win32WindowConfig `mapstructure:"win32_window" toml:"win32_window"`
 	AdbDevice   AdbDeviceConfig   `mapstructure:"adb_device" toml:"adb_device"`
 	Tasks       []Task            `mapstructure:"tasks" toml:"tasks"`
 }
 
-type Win32WindowConfig struct {
-	Screencap string `mapstructure:"screencap" toml:"screencap"`
-	Input     string `mapstructure:"intpu" toml:"intpu"`
+// GetCtrlTypes returns the controller types of the tasker in priority order.
+// ctrl_types takes precedence over the single ctrl_type.
+func (t *TaskerConfig) GetCtrlTypes() []string {
+	if len(t.CtrlTypes) > 0 {
+		return t.CtrlTypes
+	}
+	if t.CtrlType == "" {
+		return nil
+	}
+	return []string{t.CtrlType}
 }
 
-func (w *Win32WindowConfig) GetScreencapMethod() maa.Win32ScreencapMethod {
-	return strToWin32CtrlScreencapMethod(w.Screencap)
-}
+type Win32WindowConfig struct {
+	Screencap       string `mapstructure:"screencap" toml:"screencap"`
+	Input           string `mapstructure:"input" toml:"input"`
+	WindowName      string `mapstructure:"window_name" toml:"window_name"`
+	WindowNameMatch string `mapstructure:"window_name_match" toml:"window_name_match"`
+	ClassName       string `mapstructure:"class_name" toml:"class_name"`
+	ClassNameMatch  string `mapstructure:"class_name_match" toml:"class_name_match"`
+	Index           int    `mapstructure:"index" toml:"index"`
+}
+
+// The window of the CN client, used when neither window_name nor class_name is set.
+const (
+	DefaultWin32WindowName = "雷索纳斯"
+	DefaultWin32ClassName  = "UnityWndClass"
+)
 
-func (w *Win32WindowConfig) GetInputMethod() maa.Win32InputMethod {
-	return strToWin32CtrlInputMethod(w.Input)
+func (w *Win32WindowConfig) GetMatchCriteria() winmatch.Criteria {
+	criteria := winmatch.Criteria{
+		WindowName: winmatch.Pattern{Value: w.WindowName, Mode: w.WindowNameMatch},
+		ClassName:  winmatch.Pattern{Value: w.ClassName, Mode: w.ClassNameMatch},
+		Index:      w.Index,
+	}
+	if w.WindowName == "" && w.ClassName == "" {
+		criteria.WindowName = winmatch.Pattern{Value: DefaultWin32WindowName, Mode: winmatch.ModeExact}
+		criteria.ClassName = winmatch.Pattern{Value: DefaultWin32ClassName, Mode: winmatch.ModeExact}
+	}
+	return criteria
 }
 
 type AdbDeviceConfig struct {
+	AdbPath      string                 `mapstructure:"adb_path" toml:"adb_path"`
 	SerialNumber string                 `mapstructure:"serial_number" toml:"serial_number"`
 	Screencap    string                 `mapstructure:"screencap" toml:"screencap"`
 	Input        string                 `mapstructure:"input" toml:"input"`
 	Config       map[string]interface{} `mapstructure:"config" toml:"config"`
 }
 
-func (a *AdbDeviceConfig) GetScreencapMethod() maa.AdbScreencapMethod {
-	return strToAdbCtrlScreencapMethod(a.Screencap)
-}
-
-func (a *AdbDeviceConfig) GetInputMethod() maa.AdbInputMethod {
-	return strToAdbCtrlInputMethod(a.Input)
+// GetAdbPath returns the adb path of the tasker, falling back to the global adb_path.
+func (c *Config) GetAdbPath(tasker *TaskerConfig) string {
+	if tasker != nil && tasker.AdbDevice.AdbPath != "" {
+		return tasker.AdbDevice.AdbPath
+	}
+	return c.AdbPath
 }
 
 type Task struct {
@@ -75,10 +180,22 @@ func New() *Config {
 	v := viper.New()
 
 	v.SetDefault("server.port", 8000)
+	v.SetDefault("log.console", true)
+	v.SetDefault("log.color", true)
+	v.SetDefault("log.file", true)
+	v.SetDefault("log.stream_level", "info")
+	v.SetDefault("log.stream_buffer", 200)
+	v.SetDefault("reconnect.max_attempts", 5)
+	v.SetDefault("reconnect.initial_delay", 2)
+	v.SetDefault("reconnect.max_delay", 60)
+	v.SetDefault("debug.bundle_retention", 20)
+	v.SetDefault("stream.fps", 2)
+	v.SetDefault("stream.quality", 75)
 	v.SetDefault("device.adb_config", map[string]interface{}{})
 
 	v.SetConfigName("config")
 	v.SetConfigType("toml")
+	setupEnv(v, EnvPrefix, reflect.TypeOf(Config{}))
 
 	exePath, err := os.Executable()
 	if err != nil {
@@ -88,6 +205,10 @@ func New() *Config {
 	configDir := filepath.Join(exeDir, "config")
 	v.AddConfigPath(configDir)
 
+	if err := migrateFile(filepath.Join(configDir, "config.toml")); err != nil {
+		log.Fatalf("Failed to migrate config file, %v", err)
+	}
+
 	if err := v.ReadInConfig(); err != nil {
 		log.Fatalf("Failed to read config file, %v", err)
 	}
@@ -96,6 +217,9 @@ func New() *Config {
 	if err := v.Unmarshal(&config); err != nil {
 		log.Fatalf("Failed to unmarshal config file, %v", err)
 	}
+	if err := applyTaskerEnv(config.Taskers); err != nil {
+		log.Fatalf("Failed to apply tasker environment variables, %v", err)
+	}
 	return &config
 }
 
@@ -118,67 +242,3 @@ func (c *Config) Save() error {
 
 	return encode.Encode(c)
 }
-
-func strToAdbCtrlScreencapMethod(method string) maa.AdbScreencapMethod {
-	switch method {
-	case "Default":
-		return maa.AdbScreencapMethodDefault
-	case "EncodeToFileAndPull":
-		return maa.AdbScreencapMethodEncodeToFileAndPull
-	case "Encode":
-		return maa.AdbScreencapMethodEncode
-	case "RawWithGzip":
-		return maa.AdbScreencapMethodRawWithGzip
-	case "RawByNetcat":
-		return maa.AdbScreencapMethodRawByNetcat
-	case "MinicapDirect":
-		return maa.AdbScreencapMethodMinicapDirect
-	case "MinicapStream":
-		return maa.AdbScreencapMethodMinicapStream
-	case "EmulatorExtras":
-		return maa.AdbScreencapMethodEmulatorExtras
-	default:
-		return maa.AdbScreencapMethodNone
-	}
-}
-
-func strToAdbCtrlInputMethod(method string) maa.AdbInputMethod {
-	switch method {
-	case "Default":
-		return maa.AdbInputMethodDefault
-	case "AdbShell":
-		return maa.AdbInputMethodAdbShell
-	case "MinitouchAndAdbKey":
-		return maa.AdbInputMethodMinitouchAndAdbKey
-	case "Maatouch":
-		return maa.AdbInputMethodMaatouch
-	case "EmulatorExtras":
-		return maa.AdbInputMethodEmulatorExtras
-	default:
-		return maa.AdbInputMethodNone
-	}
-}
-
-func strToWin32CtrlScreencapMethod(method string) maa.Win32ScreencapMethod {
-	switch method {
-	case "GDI":
-		return maa.Win32ScreencapMethodGDI
-	case "FramePool":
-		return maa.Win32ScreencapMethodFramePool
-	case "DXGIDesktopDup":
-		return maa.Win32ScreencapMethodDXGIDesktopDup
-	default:
-		return maa.Win32ScreencapMethodNone
-	}
-}
-
-func strToWin32CtrlInputMethod(method string) maa.Win32InputMethod {
-	switch method {
-	case "Seize":
-		return maa.Win32InputMethodSeize
-	case "SendMessage":
-		return maa.Win32InputMethodSendMessage
-	default:
-		return maa.Win32InputMethodNone
-	}
-}
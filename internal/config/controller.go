@@ -0,0 +1,282 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/winmatch"
+)
+
+// The controller method types below mirror the ones of maa-framework-go and use the same bit flags,
+// so that controller options can be parsed and tested without loading the MaaFramework library.
+// The operator converts them with a plain type conversion, e.g. maa.AdbScreencapMethod(m).
+
+type AdbScreencapMethod uint64
+
+const (
+	AdbScreencapMethodNone                AdbScreencapMethod = 0
+	AdbScreencapMethodEncodeToFileAndPull AdbScreencapMethod = 1
+	AdbScreencapMethodEncode              AdbScreencapMethod = 1 << 1
+	AdbScreencapMethodRawWithGzip         AdbScreencapMethod = 1 << 2
+	AdbScreencapMethodRawByNetcat         AdbScreencapMethod = 1 << 3
+	AdbScreencapMethodMinicapDirect       AdbScreencapMethod = 1 << 4
+	AdbScreencapMethodMinicapStream       AdbScreencapMethod = 1 << 5
+	AdbScreencapMethodEmulatorExtras      AdbScreencapMethod = 1 << 6
+
+	AdbScreencapMethodAll     = ^AdbScreencapMethodNone
+	AdbScreencapMethodDefault = AdbScreencapMethodAll & (^AdbScreencapMethodRawByNetcat) & (^AdbScreencapMethodMinicapDirect) & (^AdbScreencapMethodMinicapStream)
+)
+
+type AdbInputMethod uint64
+
+const (
+	AdbInputMethodNone               AdbInputMethod = 0
+	AdbInputMethodAdbShell           AdbInputMethod = 1
+	AdbInputMethodMinitouchAndAdbKey AdbInputMethod = 1 << 1
+	AdbInputMethodMaatouch           AdbInputMethod = 1 << 2
+	AdbInputMethodEmulatorExtras     AdbInputMethod = 1 << 3
+
+	AdbInputMethodAll     = ^AdbInputMethodNone
+	AdbInputMethodDefault = AdbInputMethodAll & (^AdbInputMethodEmulatorExtras)
+)
+
+type Win32ScreencapMethod uint64
+
+const (
+	Win32ScreencapMethodNone           Win32ScreencapMethod = 0
+	Win32ScreencapMethodGDI            Win32ScreencapMethod = 1
+	Win32ScreencapMethodFramePool      Win32ScreencapMethod = 1 << 1
+	Win32ScreencapMethodDXGIDesktopDup Win32ScreencapMethod = 1 << 2
+)
+
+type Win32InputMethod uint64
+
+const (
+	Win32InputMethodNone        Win32InputMethod = 0
+	Win32InputMethodSeize       Win32InputMethod = 1
+	Win32InputMethodSendMessage Win32InputMethod = 1 << 1
+)
+
+var (
+	ErrEmptyValue     = errors.New("value is empty")
+	ErrUnknownMethod  = errors.New("unknown method")
+	ErrInvalidPattern = errors.New("invalid pattern")
+	ErrInvalidIndex   = errors.New("invalid index")
+	ErrInvalidConfig  = errors.New("invalid config")
+)
+
+// FieldError reports an invalid field of a tasker's controller config.
+// Err is one of the Err* values above and can be checked with errors.Is.
+type FieldError struct {
+	TaskerID string
+	Field    string
+	Value    string
+	Err      error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("tasker %q: invalid %s %q: %v", e.TaskerID, e.Field, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+type AdbControllerOption struct {
+	AdbPath      string
+	SerialNumber string
+	Screencap    AdbScreencapMethod
+	Input        AdbInputMethod
+	// Config is the JSON encoded adb_device.config.
+	Config string
+}
+
+// NewAdbControllerOption validates the adb_device section of tasker.
+// Every invalid field is reported as a *FieldError, joined into the returned error.
+func NewAdbControllerOption(conf *Config, tasker *TaskerConfig) (*AdbControllerOption, error) {
+	device := tasker.AdbDevice
+	var errs []error
+	fieldError := func(field, value string, err error) {
+		errs = append(errs, &FieldError{TaskerID: tasker.ID, Field: field, Value: value, Err: err})
+	}
+
+	option := &AdbControllerOption{
+		AdbPath:      conf.GetAdbPath(tasker),
+		SerialNumber: device.SerialNumber,
+		Screencap:    strToAdbCtrlScreencapMethod(device.Screencap),
+		Input:        strToAdbCtrlInputMethod(device.Input),
+		Config:       "{}",
+	}
+	if option.AdbPath == "" {
+		fieldError("adb_device.adb_path", option.AdbPath, ErrEmptyValue)
+	}
+	if option.SerialNumber == "" {
+		fieldError("adb_device.serial_number", option.SerialNumber, ErrEmptyValue)
+	}
+	if option.Screencap == AdbScreencapMethodNone {
+		fieldError("adb_device.screencap", device.Screencap, ErrUnknownMethod)
+	}
+	if option.Input == AdbInputMethodNone {
+		fieldError("adb_device.input", device.Input, ErrUnknownMethod)
+	}
+	if device.Config != nil {
+		data, err := json.Marshal(device.Config)
+		if err != nil {
+			fieldError("adb_device.config", fmt.Sprint(device.Config), fmt.Errorf("%w: %v", ErrInvalidConfig, err))
+		} else {
+			option.Config = string(data)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return option, nil
+}
+
+type Win32ControllerOption struct {
+	Criteria  winmatch.Criteria
+	Screencap Win32ScreencapMethod
+	Input     Win32InputMethod
+}
+
+// NewWin32ControllerOption validates the win32_window section of tasker.
+// Every invalid field is reported as a *FieldError, joined into the returned error.
+func NewWin32ControllerOption(tasker *TaskerConfig) (*Win32ControllerOption, error) {
+	window := tasker.Win32Window
+	var errs []error
+	fieldError := func(field, value string, err error) {
+		errs = append(errs, &FieldError{TaskerID: tasker.ID, Field: field, Value: value, Err: err})
+	}
+
+	option := &Win32ControllerOption{
+		Criteria:  window.GetMatchCriteria(),
+		Screencap: strToWin32CtrlScreencapMethod(window.Screencap),
+		Input:     strToWin32CtrlInputMethod(window.Input),
+	}
+	if option.Screencap == Win32ScreencapMethodNone {
+		fieldError("win32_window.screencap", window.Screencap, ErrUnknownMethod)
+	}
+	if option.Input == Win32InputMethodNone {
+		fieldError("win32_window.input", window.Input, ErrUnknownMethod)
+	}
+	if err := option.Criteria.WindowName.Validate(); err != nil {
+		fieldError("win32_window.window_name", window.WindowName, fmt.Errorf("%w: %v", ErrInvalidPattern, err))
+	}
+	if err := option.Criteria.ClassName.Validate(); err != nil {
+		fieldError("win32_window.class_name", window.ClassName, fmt.Errorf("%w: %v", ErrInvalidPattern, err))
+	}
+	if window.Index < 0 {
+		fieldError("win32_window.index", fmt.Sprint(window.Index), ErrInvalidIndex)
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return option, nil
+}
+
+var (
+	adbScreencapMethodNames = []string{
+		"EncodeToFileAndPull",
+		"Encode",
+		"RawWithGzip",
+		"RawByNetcat",
+		"MinicapDirect",
+		"MinicapStream",
+		"EmulatorExtras",
+	}
+	adbInputMethodNames = []string{
+		"AdbShell",
+		"MinitouchAndAdbKey",
+		"Maatouch",
+		"EmulatorExtras",
+	}
+)
+
+// AdbScreencapMethodNames returns the config names of every single method set in method.
+func AdbScreencapMethodNames(method AdbScreencapMethod) []string {
+	names := make([]string, 0, len(adbScreencapMethodNames))
+	for _, name := range adbScreencapMethodNames {
+		if method&strToAdbCtrlScreencapMethod(name) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// AdbInputMethodNames returns the config names of every single method set in method.
+func AdbInputMethodNames(method AdbInputMethod) []string {
+	names := make([]string, 0, len(adbInputMethodNames))
+	for _, name := range adbInputMethodNames {
+		if method&strToAdbCtrlInputMethod(name) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func strToAdbCtrlScreencapMethod(method string) AdbScreencapMethod {
+	switch method {
+	case "Default":
+		return AdbScreencapMethodDefault
+	case "EncodeToFileAndPull":
+		return AdbScreencapMethodEncodeToFileAndPull
+	case "Encode":
+		return AdbScreencapMethodEncode
+	case "RawWithGzip":
+		return AdbScreencapMethodRawWithGzip
+	case "RawByNetcat":
+		return AdbScreencapMethodRawByNetcat
+	case "MinicapDirect":
+		return AdbScreencapMethodMinicapDirect
+	case "MinicapStream":
+		return AdbScreencapMethodMinicapStream
+	case "EmulatorExtras":
+		return AdbScreencapMethodEmulatorExtras
+	default:
+		return AdbScreencapMethodNone
+	}
+}
+
+func strToAdbCtrlInputMethod(method string) AdbInputMethod {
+	switch method {
+	case "Default":
+		return AdbInputMethodDefault
+	case "AdbShell":
+		return AdbInputMethodAdbShell
+	case "MinitouchAndAdbKey":
+		return AdbInputMethodMinitouchAndAdbKey
+	case "Maatouch":
+		return AdbInputMethodMaatouch
+	case "EmulatorExtras":
+		return AdbInputMethodEmulatorExtras
+	default:
+		return AdbInputMethodNone
+	}
+}
+
+func strToWin32CtrlScreencapMethod(method string) Win32ScreencapMethod {
+	switch method {
+	case "GDI":
+		return Win32ScreencapMethodGDI
+	case "FramePool":
+		return Win32ScreencapMethodFramePool
+	case "DXGIDesktopDup":
+		return Win32ScreencapMethodDXGIDesktopDup
+	default:
+		return Win32ScreencapMethodNone
+	}
+}
+
+func strToWin32CtrlInputMethod(method string) Win32InputMethod {
+	switch method {
+	case "Seize":
+		return Win32InputMethodSeize
+	case "SendMessage":
+		return Win32InputMethodSendMessage
+	default:
+		return Win32InputMethodNone
+	}
+}
@@ -0,0 +1,275 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrToAdbCtrlScreencapMethod(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Expect AdbScreencapMethod
+	}{
+		{"Default", AdbScreencapMethodDefault},
+		{"EncodeToFileAndPull", AdbScreencapMethodEncodeToFileAndPull},
+		{"Encode", AdbScreencapMethodEncode},
+		{"RawWithGzip", AdbScreencapMethodRawWithGzip},
+		{"RawByNetcat", AdbScreencapMethodRawByNetcat},
+		{"MinicapDirect", AdbScreencapMethodMinicapDirect},
+		{"MinicapStream", AdbScreencapMethodMinicapStream},
+		{"EmulatorExtras", AdbScreencapMethodEmulatorExtras},
+		{"", AdbScreencapMethodNone},
+		{"default", AdbScreencapMethodNone},
+		{"GDI", AdbScreencapMethodNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Expect, strToAdbCtrlScreencapMethod(tc.Name))
+		})
+	}
+}
+
+func TestStrToAdbCtrlInputMethod(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Expect AdbInputMethod
+	}{
+		{"Default", AdbInputMethodDefault},
+		{"AdbShell", AdbInputMethodAdbShell},
+		{"MinitouchAndAdbKey", AdbInputMethodMinitouchAndAdbKey},
+		{"Maatouch", AdbInputMethodMaatouch},
+		{"EmulatorExtras", AdbInputMethodEmulatorExtras},
+		{"", AdbInputMethodNone},
+		{"maatouch", AdbInputMethodNone},
+		{"Seize", AdbInputMethodNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Expect, strToAdbCtrlInputMethod(tc.Name))
+		})
+	}
+}
+
+func TestStrToWin32CtrlScreencapMethod(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Expect Win32ScreencapMethod
+	}{
+		{"GDI", Win32ScreencapMethodGDI},
+		{"FramePool", Win32ScreencapMethodFramePool},
+		{"DXGIDesktopDup", Win32ScreencapMethodDXGIDesktopDup},
+		{"", Win32ScreencapMethodNone},
+		{"gdi", Win32ScreencapMethodNone},
+		{"Default", Win32ScreencapMethodNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Expect, strToWin32CtrlScreencapMethod(tc.Name))
+		})
+	}
+}
+
+func TestStrToWin32CtrlInputMethod(t *testing.T) {
+	testCases := []struct {
+		Name   string
+		Expect Win32InputMethod
+	}{
+		{"Seize", Win32InputMethodSeize},
+		{"SendMessage", Win32InputMethodSendMessage},
+		{"", Win32InputMethodNone},
+		{"seize", Win32InputMethodNone},
+		{"Default", Win32InputMethodNone},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Expect, strToWin32CtrlInputMethod(tc.Name))
+		})
+	}
+}
+
+func TestAdbMethodNames(t *testing.T) {
+	require.Equal(t, []string{"EncodeToFileAndPull", "Encode", "RawWithGzip", "EmulatorExtras"}, AdbScreencapMethodNames(AdbScreencapMethodDefault))
+	require.Equal(t, []string{"AdbShell", "MinitouchAndAdbKey", "Maatouch"}, AdbInputMethodNames(AdbInputMethodDefault))
+	require.Empty(t, AdbScreencapMethodNames(AdbScreencapMethodNone))
+	require.Empty(t, AdbInputMethodNames(AdbInputMethodNone))
+
+	for _, name := range adbScreencapMethodNames {
+		require.Equal(t, []string{name}, AdbScreencapMethodNames(strToAdbCtrlScreencapMethod(name)))
+	}
+	for _, name := range adbInputMethodNames {
+		require.Equal(t, []string{name}, AdbInputMethodNames(strToAdbCtrlInputMethod(name)))
+	}
+}
+
+// requireFieldErrors checks that err reports exactly the given fields, each wrapping the expected cause.
+func requireFieldErrors(t *testing.T, err error, expect map[string]error) {
+	t.Helper()
+	if len(expect) == 0 {
+		require.NoError(t, err)
+		return
+	}
+	require.Error(t, err)
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	require.True(t, ok, "expected joined errors, got %T", err)
+	got := map[string]error{}
+	for _, e := range joined.Unwrap() {
+		var fieldErr *FieldError
+		require.True(t, errors.As(e, &fieldErr), "expected *FieldError, got %T", e)
+		got[fieldErr.Field] = fieldErr
+	}
+	require.Len(t, got, len(expect))
+	for field, cause := range expect {
+		require.Contains(t, got, field)
+		require.ErrorIs(t, got[field], cause, "field %s", field)
+	}
+}
+
+func TestNewAdbControllerOption(t *testing.T) {
+	testCases := []struct {
+		Name         string
+		Conf         *Config
+		Device       AdbDeviceConfig
+		ExpectOption *AdbControllerOption
+		ExpectErrs   map[string]error
+	}{
+		{
+			Name: "Valid",
+			Conf: &Config{},
+			Device: AdbDeviceConfig{
+				AdbPath:      "/path/to/adb",
+				SerialNumber: "127.0.0.1:5555",
+				Screencap:    "Default",
+				Input:        "Maatouch",
+				Config:       map[string]interface{}{"extras": map[string]interface{}{}},
+			},
+			ExpectOption: &AdbControllerOption{
+				AdbPath:      "/path/to/adb",
+				SerialNumber: "127.0.0.1:5555",
+				Screencap:    AdbScreencapMethodDefault,
+				Input:        AdbInputMethodMaatouch,
+				Config:       `{"extras":{}}`,
+			},
+		},
+		{
+			Name: "Global Adb Path And Empty Config",
+			Conf: &Config{AdbPath: "/path/to/global/adb"},
+			Device: AdbDeviceConfig{
+				SerialNumber: "127.0.0.1:5555",
+				Screencap:    "Encode",
+				Input:        "Default",
+			},
+			ExpectOption: &AdbControllerOption{
+				AdbPath:      "/path/to/global/adb",
+				SerialNumber: "127.0.0.1:5555",
+				Screencap:    AdbScreencapMethodEncode,
+				Input:        AdbInputMethodDefault,
+				Config:       "{}",
+			},
+		},
+		{
+			Name: "Invalid Input Only",
+			Conf: &Config{},
+			Device: AdbDeviceConfig{
+				AdbPath:      "/path/to/adb",
+				SerialNumber: "127.0.0.1:5555",
+				Screencap:    "Default",
+				Input:        "Seize",
+			},
+			ExpectErrs: map[string]error{
+				"adb_device.input": ErrUnknownMethod,
+			},
+		},
+		{
+			Name: "Every Field Invalid",
+			Conf: &Config{},
+			Device: AdbDeviceConfig{
+				Screencap: "GDI",
+				Config:    map[string]interface{}{"bad": make(chan int)},
+			},
+			ExpectErrs: map[string]error{
+				"adb_device.adb_path":      ErrEmptyValue,
+				"adb_device.serial_number": ErrEmptyValue,
+				"adb_device.screencap":     ErrUnknownMethod,
+				"adb_device.input":         ErrUnknownMethod,
+				"adb_device.config":        ErrInvalidConfig,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			tasker := &TaskerConfig{ID: "test", AdbDevice: tc.Device}
+			option, err := NewAdbControllerOption(tc.Conf, tasker)
+			requireFieldErrors(t, err, tc.ExpectErrs)
+			require.Equal(t, tc.ExpectOption, option)
+		})
+	}
+}
+
+func TestNewWin32ControllerOption(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Window     Win32WindowConfig
+		ExpectErrs map[string]error
+	}{
+		{
+			Name: "Valid",
+			Window: Win32WindowConfig{
+				Screencap:       "FramePool",
+				Input:           "SendMessage",
+				WindowName:      "Resonance",
+				WindowNameMatch: "contains",
+				Index:           1,
+			},
+		},
+		{
+			Name: "Invalid Screencap Only",
+			Window: Win32WindowConfig{
+				Screencap: "Default",
+				Input:     "Seize",
+			},
+			ExpectErrs: map[string]error{
+				"win32_window.screencap": ErrUnknownMethod,
+			},
+		},
+		{
+			Name: "Every Field Invalid",
+			Window: Win32WindowConfig{
+				Screencap:       "gdi",
+				Input:           "Default",
+				WindowName:      "(",
+				WindowNameMatch: "regex",
+				ClassName:       "UnityWndClass",
+				ClassNameMatch:  "glob",
+				Index:           -1,
+			},
+			ExpectErrs: map[string]error{
+				"win32_window.screencap":   ErrUnknownMethod,
+				"win32_window.input":       ErrUnknownMethod,
+				"win32_window.window_name": ErrInvalidPattern,
+				"win32_window.class_name":  ErrInvalidPattern,
+				"win32_window.index":       ErrInvalidIndex,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			tasker := &TaskerConfig{ID: "test", Win32Window: tc.Window}
+			option, err := NewWin32ControllerOption(tasker)
+			requireFieldErrors(t, err, tc.ExpectErrs)
+			if len(tc.ExpectErrs) > 0 {
+				require.Nil(t, option)
+			} else {
+				require.NotNil(t, option)
+			}
+		})
+	}
+}
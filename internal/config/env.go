@@ -0,0 +1,89 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/spf13/viper"
+)
+
+// EnvPrefix is the prefix of every environment variable that overrides the config file.
+//
+// Global keys map to EAM_<KEY>, nested keys are joined with underscores,
+// e.g. EAM_SERVER_PORT or EAM_LOG_LEVEL.
+//
+// Tasker keys are addressed by tasker ID: EAM_TASKERS_<ID>_<KEY>,
+// e.g. EAM_TASKERS_F99BBA5C_7A24_4590_A328_A998B215F6CD_ADB_DEVICE_SERIAL_NUMBER.
+// The ID is upper-cased and every character that is not a letter or digit becomes an underscore.
+const EnvPrefix = "EAM"
+
+var envKeyReplacer = strings.NewReplacer(".", "_")
+
+func setupEnv(v *viper.Viper, prefix string, t reflect.Type) {
+	v.SetEnvPrefix(prefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	v.AutomaticEnv()
+	bindEnvs(v, "", t)
+}
+
+// bindEnvs binds every scalar key of t, so that Unmarshal also sees environment
+// variables for keys which are missing from the config file.
+// Slices and maps can not be expressed as a single variable and are skipped.
+func bindEnvs(v *viper.Viper, parent string, t reflect.Type) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := tag
+		if parent != "" {
+			key = parent + "." + tag
+		}
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		switch ft.Kind() {
+		case reflect.Struct:
+			bindEnvs(v, key, ft)
+		case reflect.Slice, reflect.Array, reflect.Map, reflect.Interface:
+			continue
+		default:
+			_ = v.BindEnv(key)
+		}
+	}
+}
+
+// applyTaskerEnv overrides the fields of each tasker with its EAM_TASKERS_<ID>_* variables.
+func applyTaskerEnv(taskers []*TaskerConfig) error {
+	for _, tasker := range taskers {
+		if tasker == nil || tasker.ID == "" {
+			continue
+		}
+		v := viper.New()
+		setupEnv(v, taskerEnvPrefix(tasker.ID), reflect.TypeOf(tasker))
+		if len(v.AllSettings()) == 0 {
+			continue
+		}
+		if err := v.Unmarshal(tasker); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func taskerEnvPrefix(id string) string {
+	normalized := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, id)
+	return EnvPrefix + "_TASKERS_" + normalized
+}
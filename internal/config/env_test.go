@@ -0,0 +1,73 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetupEnv(t *testing.T) {
+	t.Setenv("EAM_SERVER_PORT", "9000")
+	t.Setenv("EAM_LOG_STREAM_LEVEL", "debug")
+	t.Setenv("EAM_ADB_PATH", "/usr/bin/adb")
+
+	v := viper.New()
+	v.Set("log.level", "info")
+	setupEnv(v, EnvPrefix, reflect.TypeOf(Config{}))
+
+	var conf Config
+	require.NoError(t, v.Unmarshal(&conf))
+	require.Equal(t, 9000, conf.Server.Port)
+	require.Equal(t, "info", conf.Log.Level)
+	require.Equal(t, "debug", conf.Log.StreamLevel)
+	require.Equal(t, "/usr/bin/adb", conf.AdbPath)
+}
+
+func TestApplyTaskerEnv(t *testing.T) {
+	t.Setenv("EAM_TASKERS_F99BBA5C_7A24_ADB_DEVICE_SERIAL_NUMBER", "127.0.0.1:16384")
+	t.Setenv("EAM_TASKERS_F99BBA5C_7A24_CTRL_TYPE", "adb")
+	t.Setenv("EAM_TASKERS_OTHER_NAME", "other")
+
+	taskers := []*TaskerConfig{
+		{
+			ID:       "f99bba5c-7a24",
+			Name:     "main",
+			CtrlType: "win32",
+			AdbDevice: AdbDeviceConfig{
+				SerialNumber: "127.0.0.1:5555",
+				Input:        "Default",
+			},
+		},
+		{
+			ID:   "second",
+			Name: "second",
+		},
+	}
+	require.NoError(t, applyTaskerEnv(taskers))
+
+	require.Equal(t, "adb", taskers[0].CtrlType)
+	require.Equal(t, "127.0.0.1:16384", taskers[0].AdbDevice.SerialNumber)
+	require.Equal(t, "Default", taskers[0].AdbDevice.Input)
+	require.Equal(t, "main", taskers[0].Name)
+	require.Equal(t, "second", taskers[1].Name)
+}
+
+func TestTaskerEnvPrefix(t *testing.T) {
+	testCases := []struct {
+		ID     string
+		Expect string
+	}{
+		{"main", "EAM_TASKERS_MAIN"},
+		{"f99bba5c-7a24-4590-a328-a998b215f6cd", "EAM_TASKERS_F99BBA5C_7A24_4590_A328_A998B215F6CD"},
+		{"my tasker.2", "EAM_TASKERS_MY_TASKER_2"},
+		{"日服", "EAM_TASKERS___"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ID, func(t *testing.T) {
+			require.Equal(t, tc.Expect, taskerEnvPrefix(tc.ID))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config/migration"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// migrateFile upgrades the config file at path to the latest format version.
+// The original file is kept next to it as config.toml.v<version>.<time>.bak before the upgraded file is saved.
+// The upgraded file is encoded from the decoded values, so it loses the comments and the key order of the original file.
+// A header comment points to the backup which keeps them.
+func migrateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var tree map[string]interface{}
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return err
+	}
+	if tree == nil {
+		tree = map[string]interface{}{}
+	}
+
+	from, to, err := migration.Migrate(tree)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d.%s.bak", path, from, time.Now().Format("20060102150405"))
+	if err := os.WriteFile(backup, data, 0644); err != nil {
+		return fmt.Errorf("failed to back up config file: %w", err)
+	}
+
+	upgraded, err := toml.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	header := fmt.Sprintf("# Migrated from config version %d to %d. Comments were dropped, see %s for the original file.\n\n", from, to, filepath.Base(backup))
+	if err := os.WriteFile(path, append([]byte(header), upgraded...), 0644); err != nil {
+		return err
+	}
+	log.Printf("Migrated config file from version %d to %d. Comments and key order were dropped, the original file is saved as %s", from, to, backup)
+	return nil
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config/migration"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/stretchr/testify/require"
+)
+
+const commentedConfig = `# Shipped config
+adb_path = "/usr/bin/adb"
+
+[server]
+# Port of the web ui
+port = 8000
+
+[[taskers]]
+id = "main"
+# Capture the window with GDI
+[taskers.win32_window]
+screencap = "GDI"
+intpu = "Seize"
+`
+
+func TestMigrateFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(path, []byte(commentedConfig), 0644))
+
+	require.NoError(t, migrateFile(path))
+
+	backups, err := filepath.Glob(filepath.Join(dir, "config.toml.v0.*.bak"))
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+	original, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	require.Equal(t, commentedConfig, string(original))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(data), "# Migrated from config version 0 to "))
+	require.Contains(t, string(data), filepath.Base(backups[0]))
+	require.NotContains(t, string(data), "# Port of the web ui")
+
+	var tree map[string]interface{}
+	require.NoError(t, toml.Unmarshal(data, &tree))
+	require.EqualValues(t, migration.Latest(), tree[migration.VersionKey])
+	tasker := tree["taskers"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, "Seize", tasker["win32_window"].(map[string]interface{})["input"])
+	require.Equal(t, "/usr/bin/adb", tasker["adb_device"].(map[string]interface{})["adb_path"])
+}
+
+func TestMigrateFileUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	data := "# Kept as is\nconfig_version = " + strconv.Itoa(migration.Latest()) + "\n"
+	require.NoError(t, os.WriteFile(path, []byte(data), 0644))
+
+	require.NoError(t, migrateFile(path))
+
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, data, string(got))
+	backups, err := filepath.Glob(filepath.Join(dir, "*.bak"))
+	require.NoError(t, err)
+	require.Empty(t, backups)
+}
@@ -0,0 +1,66 @@
+package migration
+
+import (
+	"fmt"
+)
+
+// VersionKey is the top level key that records the format version of a config file.
+const VersionKey = "config_version"
+
+// Migration upgrades a decoded config file from Version-1 to Version.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tree map[string]interface{}) error
+}
+
+// Latest returns the version produced by the last migration.
+func Latest() int {
+	if len(migrations) == 0 {
+		return 0
+	}
+	return migrations[len(migrations)-1].Version
+}
+
+// Version returns the format version of tree. A file without a version is version 0.
+func Version(tree map[string]interface{}) (int, error) {
+	raw, exists := tree[VersionKey]
+	if !exists {
+		return 0, nil
+	}
+	switch v := raw.(type) {
+	case int:
+		return v, nil
+	case int64:
+		return int(v), nil
+	case float64:
+		return int(v), nil
+	default:
+		return 0, fmt.Errorf("invalid %s: %v", VersionKey, raw)
+	}
+}
+
+// Migrate upgrades tree in place, one version at a time, up to Latest.
+// It returns the version tree had before and after the upgrade.
+func Migrate(tree map[string]interface{}) (from, to int, err error) {
+	from, err = Version(tree)
+	if err != nil {
+		return 0, 0, err
+	}
+	if from > Latest() {
+		return from, from, fmt.Errorf("config version %d is newer than the supported version %d", from, Latest())
+	}
+
+	to = from
+	for _, m := range migrations {
+		if m.Version <= to {
+			continue
+		}
+		if err := m.Up(tree); err != nil {
+			return from, to, fmt.Errorf("failed to migrate config to version %d (%s): %w", m.Version, m.Description, err)
+		}
+		to = m.Version
+		tree[VersionKey] = to
+	}
+	return from, to, nil
+}
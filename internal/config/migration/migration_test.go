@@ -0,0 +1,138 @@
+package migration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Tree       map[string]interface{}
+		ExpectFrom int
+		ExpectTree map[string]interface{}
+		ExpectErr  bool
+	}{
+		{
+			Name: "Unversioned File",
+			Tree: map[string]interface{}{
+				"taskers": []interface{}{
+					map[string]interface{}{
+						"id":           "1",
+						"win32_window": map[string]interface{}{"screencap": "GDI", "intpu": "Seize"},
+					},
+				},
+			},
+			ExpectFrom: 0,
+			ExpectTree: map[string]interface{}{
+				VersionKey: Latest(),
+				"taskers": []interface{}{
+					map[string]interface{}{
+						"id":           "1",
+						"win32_window": map[string]interface{}{"screencap": "GDI", "input": "Seize"},
+					},
+				},
+			},
+		},
+		{
+			Name: "Existing Key Wins",
+			Tree: map[string]interface{}{
+				"taskers": []interface{}{
+					map[string]interface{}{
+						"win32_window": map[string]interface{}{"intpu": "Seize", "input": "SendMessage"},
+					},
+				},
+			},
+			ExpectFrom: 0,
+			ExpectTree: map[string]interface{}{
+				VersionKey: Latest(),
+				"taskers": []interface{}{
+					map[string]interface{}{
+						"win32_window": map[string]interface{}{"input": "SendMessage"},
+					},
+				},
+			},
+		},
+		{
+			Name: "Global Adb Path",
+			Tree: map[string]interface{}{
+				VersionKey: int64(1),
+				"adb_path": "/path/to/adb",
+				"taskers": []interface{}{
+					map[string]interface{}{"id": "1"},
+					map[string]interface{}{
+						"id":         "2",
+						"adb_device": map[string]interface{}{"adb_path": "/path/to/mumu/adb"},
+					},
+				},
+			},
+			ExpectFrom: 1,
+			ExpectTree: map[string]interface{}{
+				VersionKey: Latest(),
+				"taskers": []interface{}{
+					map[string]interface{}{
+						"id":         "1",
+						"adb_device": map[string]interface{}{"adb_path": "/path/to/adb"},
+					},
+					map[string]interface{}{
+						"id":         "2",
+						"adb_device": map[string]interface{}{"adb_path": "/path/to/mumu/adb"},
+					},
+				},
+			},
+		},
+		{
+			Name: "Global Adb Path Without Taskers",
+			Tree: map[string]interface{}{
+				VersionKey: int64(1),
+				"adb_path": "/path/to/adb",
+			},
+			ExpectFrom: 1,
+			ExpectTree: map[string]interface{}{
+				VersionKey: Latest(),
+				"adb_path": "/path/to/adb",
+			},
+		},
+		{
+			Name:       "Latest File",
+			Tree:       map[string]interface{}{VersionKey: int64(Latest())},
+			ExpectFrom: Latest(),
+			ExpectTree: map[string]interface{}{VersionKey: int64(Latest())},
+		},
+		{
+			Name:       "Newer File",
+			Tree:       map[string]interface{}{VersionKey: int64(Latest() + 1)},
+			ExpectFrom: Latest() + 1,
+			ExpectTree: map[string]interface{}{VersionKey: int64(Latest() + 1)},
+			ExpectErr:  true,
+		},
+		{
+			Name:       "Invalid Version",
+			Tree:       map[string]interface{}{VersionKey: "one"},
+			ExpectErr:  true,
+			ExpectTree: map[string]interface{}{VersionKey: "one"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			from, to, err := Migrate(tc.Tree)
+			if tc.ExpectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, Latest(), to)
+			}
+			require.Equal(t, tc.ExpectFrom, from)
+			require.Equal(t, tc.ExpectTree, tc.Tree)
+		})
+	}
+}
+
+func TestMigrationsAreSequential(t *testing.T) {
+	for i, m := range migrations {
+		require.Equal(t, i+1, m.Version, "migration %q is out of order", m.Description)
+		require.NotNil(t, m.Up, "migration %q has no Up func", m.Description)
+	}
+}
@@ -0,0 +1,75 @@
+package migration
+
+// migrations must be sorted by Version, without gaps, starting at 1.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "rename taskers.win32_window.intpu to input",
+		Up:          renameWin32WindowInput,
+	},
+	{
+		Version:     2,
+		Description: "move adb_path into taskers.adb_device",
+		Up:          moveAdbPathToTaskers,
+	},
+}
+
+// taskers returns every tasker table of tree.
+func taskers(tree map[string]interface{}) []map[string]interface{} {
+	list, ok := tree["taskers"].([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if tasker, ok := item.(map[string]interface{}); ok {
+			result = append(result, tasker)
+		}
+	}
+	return result
+}
+
+// renameKey moves table[from] to table[to] unless table[to] is already set.
+func renameKey(table map[string]interface{}, from, to string) {
+	value, exists := table[from]
+	if !exists {
+		return
+	}
+	delete(table, from)
+	if _, exists := table[to]; !exists {
+		table[to] = value
+	}
+}
+
+func renameWin32WindowInput(tree map[string]interface{}) error {
+	for _, tasker := range taskers(tree) {
+		if window, ok := tasker["win32_window"].(map[string]interface{}); ok {
+			renameKey(window, "intpu", "input")
+		}
+	}
+	return nil
+}
+
+func moveAdbPathToTaskers(tree map[string]interface{}) error {
+	adbPath, exists := tree["adb_path"]
+	if !exists {
+		return nil
+	}
+	list := taskers(tree)
+	if len(list) == 0 {
+		// Keep the global path as the fallback for taskers added later.
+		return nil
+	}
+	for _, tasker := range list {
+		device, ok := tasker["adb_device"].(map[string]interface{})
+		if !ok {
+			device = map[string]interface{}{}
+			tasker["adb_device"] = device
+		}
+		if _, exists := device["adb_path"]; !exists {
+			device["adb_path"] = adbPath
+		}
+	}
+	delete(tree, "adb_path")
+	return nil
+}
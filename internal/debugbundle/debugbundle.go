@@ -0,0 +1,260 @@
+package debugbundle
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Files of a bundle.
+const (
+	ScreenshotFile = "screenshot.png"
+	DetailFile     = "detail.json"
+)
+
+var ErrNotFound = errors.New("debug bundle not found")
+
+type Recognition struct {
+	Name      string          `json:"name"`
+	Algorithm string          `json:"algorithm"`
+	Hit       bool            `json:"hit"`
+	Box       [4]int32        `json:"box"`
+	Detail    json.RawMessage `json:"detail,omitempty"`
+}
+
+// Detail is saved as detail.json of a bundle.
+type Detail struct {
+	Name          string        `json:"name"`
+	TaskerID      string        `json:"tasker_id"`
+	RunID         string        `json:"run_id"`
+	Entry         string        `json:"entry"`
+	Param         string        `json:"param"`
+	Error         string        `json:"error"`
+	CreatedAt     time.Time     `json:"created_at"`
+	HasScreenshot bool          `json:"has_screenshot"`
+	Recognitions  []Recognition `json:"recognitions"`
+}
+
+// Store keeps debug bundles as directories under dir, deleting the oldest ones beyond retention.
+type Store struct {
+	dir       string
+	retention int
+	mutex     sync.Mutex
+}
+
+// NewStore returns a store in dir. A retention of 0 or less keeps every bundle.
+func NewStore(dir string, retention int) *Store {
+	return &Store{
+		dir:       dir,
+		retention: retention,
+	}
+}
+
+// DefaultDir returns debug/bundles next to the executable.
+func DefaultDir() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "debug", "bundles"), nil
+}
+
+// NewDefaultStore returns the store in DefaultDir, or in the same directory relative to the
+// working directory if the executable can not be located.
+func NewDefaultStore(retention int) *Store {
+	dir, err := DefaultDir()
+	if err != nil {
+		dir = filepath.Join("debug", "bundles")
+	}
+	return NewStore(dir, retention)
+}
+
+// Save writes img and detail as a new bundle and returns its name.
+// img may be nil if no screencap is available.
+func (s *Store) Save(detail Detail, img image.Image) (string, error) {
+	if detail.CreatedAt.IsZero() {
+		detail.CreatedAt = time.Now()
+	}
+	detail.Name = bundleName(detail)
+	detail.HasScreenshot = img != nil
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dir := filepath.Join(s.dir, detail.Name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	if img != nil {
+		file, err := os.Create(filepath.Join(dir, ScreenshotFile))
+		if err != nil {
+			return "", err
+		}
+		err = png.Encode(file, img)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	data, err := json.MarshalIndent(detail, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, DetailFile), data, 0600); err != nil {
+		return "", err
+	}
+
+	return detail.Name, s.prune()
+}
+
+// List returns the details of every bundle, newest first.
+func (s *Store) List() ([]Detail, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	names, err := s.names()
+	if err != nil {
+		return nil, err
+	}
+	details := make([]Detail, 0, len(names))
+	for i := len(names) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(filepath.Join(s.dir, names[i], DetailFile))
+		if err != nil {
+			continue
+		}
+		var detail Detail
+		if err := json.Unmarshal(data, &detail); err != nil {
+			continue
+		}
+		details = append(details, detail)
+	}
+	return details, nil
+}
+
+// File returns the path of file in the bundle name.
+func (s *Store) File(name, file string) (string, error) {
+	if file != ScreenshotFile && file != DetailFile {
+		return "", ErrNotFound
+	}
+	dir, err := s.bundleDir(name)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, file)
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrNotFound
+	}
+	return path, nil
+}
+
+// WriteZip writes the bundle name as a zip archive to w.
+func (s *Store) WriteZip(name string, w io.Writer) error {
+	dir, err := s.bundleDir(name)
+	if err != nil {
+		return err
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range []string{DetailFile, ScreenshotFile} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		entry, err := archive.Create(name + "/" + file)
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func (s *Store) bundleDir(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrNotFound
+	}
+	dir := filepath.Join(s.dir, name)
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return "", ErrNotFound
+	}
+	return dir, nil
+}
+
+// names returns the bundle directories sorted from the oldest to the newest.
+func (s *Store) names() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	// Names start with the creation time, so they sort chronologically.
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *Store) prune() error {
+	if s.retention <= 0 {
+		return nil
+	}
+	names, err := s.names()
+	if err != nil {
+		return err
+	}
+	for len(names) > s.retention {
+		if err := os.RemoveAll(filepath.Join(s.dir, names[0])); err != nil {
+			return err
+		}
+		names = names[1:]
+	}
+	return nil
+}
+
+func bundleName(detail Detail) string {
+	return fmt.Sprintf("%s_%s_%s",
+		detail.CreatedAt.Format("20060102-150405.000"),
+		sanitize(detail.TaskerID),
+		sanitize(detail.Entry),
+	)
+}
+
+// sanitize keeps a name safe to use as part of a directory name.
+func sanitize(name string) string {
+	if name == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
@@ -0,0 +1,67 @@
+package debugbundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	store := NewStore(t.TempDir(), 2)
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	start := time.Date(2024, 12, 1, 2, 0, 0, 0, time.UTC)
+
+	var names []string
+	for i, entry := range []string{"Startup", "MapNavigation", "Shopping/Freeport"} {
+		name, err := store.Save(Detail{
+			TaskerID:  "f99bba5c",
+			Entry:     entry,
+			Error:     "failed to complete the task",
+			CreatedAt: start.Add(time.Duration(i) * time.Minute),
+			Recognitions: []Recognition{
+				{Name: entry, Algorithm: "OCR", Box: [4]int32{1, 2, 3, 4}},
+			},
+		}, img)
+		require.NoError(t, err)
+		names = append(names, name)
+	}
+	require.Equal(t, "20241201-020200.000_f99bba5c_Shopping_Freeport", names[2])
+
+	details, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, details, 2, "the oldest bundle should be pruned")
+	require.Equal(t, names[2], details[0].Name)
+	require.Equal(t, names[1], details[1].Name)
+	require.True(t, details[0].HasScreenshot)
+
+	_, err = store.File(names[0], DetailFile)
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = store.File(names[2], ScreenshotFile)
+	require.NoError(t, err)
+	_, err = store.File(names[2], "../"+names[1])
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = store.File("..", DetailFile)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	var buf bytes.Buffer
+	require.NoError(t, store.WriteZip(names[2], &buf))
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, archive.File, 2)
+}
+
+func TestSaveWithoutScreenshot(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+	name, err := store.Save(Detail{TaskerID: "a", Entry: ""}, nil)
+	require.NoError(t, err)
+
+	_, err = store.File(name, ScreenshotFile)
+	require.ErrorIs(t, err, ErrNotFound)
+	path, err := store.File(name, DetailFile)
+	require.NoError(t, err)
+	require.FileExists(t, path)
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dongwlin/elf-aid-magic/internal/debugbundle"
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type BundleHandler struct {
+	logger      *zap.Logger
+	bundleLogic *logic.BundleLogic
+}
+
+func NewBundleHandler(logger *zap.Logger, bundleLogic *logic.BundleLogic) *BundleHandler {
+	return &BundleHandler{
+		logger:      logger,
+		bundleLogic: bundleLogic,
+	}
+}
+
+func (h *BundleHandler) Register(r fiber.Router) {
+	bundles := r.Group("/bundles")
+	bundles.Get("/", h.GetBundles)
+	bundles.Get("/:name", h.DownloadBundle)
+	bundles.Get("/:name/:file", h.GetBundleFile)
+}
+
+func (h *BundleHandler) GetBundles(c *fiber.Ctx) error {
+	bundles, err := h.bundleLogic.GetBundles()
+	if err != nil {
+		h.logger.Error("failed to list debug bundles",
+			zap.Error(err),
+		)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Failed to list debug bundles."})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"bundles": bundles,
+	})
+}
+
+// DownloadBundle sends the bundle as a zip archive.
+func (h *BundleHandler) DownloadBundle(c *fiber.Ctx) error {
+	name := c.Params("name")
+	var buf bytes.Buffer
+	if err := h.bundleLogic.WriteBundleZip(name, &buf); err != nil {
+		if errors.Is(err, debugbundle.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Debug bundle not found."})
+		}
+		h.logger.Error("failed to archive debug bundle",
+			zap.String("name", name),
+			zap.Error(err),
+		)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Failed to archive debug bundle."})
+	}
+	c.Attachment(name + ".zip")
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
+
+// GetBundleFile sends screenshot.png or detail.json of the bundle.
+func (h *BundleHandler) GetBundleFile(c *fiber.Ctx) error {
+	path, err := h.bundleLogic.GetBundleFile(c.Params("name"), c.Params("file"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Debug bundle file not found."})
+	}
+	return c.SendFile(path)
+}
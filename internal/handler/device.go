@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type DeviceHandler struct {
+	logger      *zap.Logger
+	deviceLogic *logic.DeviceLogic
+}
+
+func NewDeviceHandler(logger *zap.Logger, deviceLogic *logic.DeviceLogic) *DeviceHandler {
+	return &DeviceHandler{
+		logger:      logger,
+		deviceLogic: deviceLogic,
+	}
+}
+
+func (h *DeviceHandler) Register(r fiber.Router) {
+	r.Get("/devices", h.GetDevices)
+}
+
+func (h *DeviceHandler) GetDevices(c *fiber.Ctx) error {
+	adbPath := c.Query("adb_path")
+	devices, err := h.deviceLogic.FindConfiguredAdbDevices(adbPath)
+	if err != nil {
+		h.logger.Warn("reject adb path",
+			zap.String("adb path", adbPath),
+			zap.Error(err),
+		)
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "The adb path must be one of the adb paths of the config."})
+	}
+	h.logger.Info("find adb devices",
+		zap.String("adb path", adbPath),
+		zap.Int("count", len(devices)),
+	)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"devices": devices,
+	})
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthHandler struct {
+	healthLogic *logic.HealthLogic
+}
+
+func NewHealthHandler(healthLogic *logic.HealthLogic) *HealthHandler {
+	return &HealthHandler{
+		healthLogic: healthLogic,
+	}
+}
+
+func (h *HealthHandler) Register(r fiber.Router) {
+	r.Get("/healthz", h.Health)
+	r.Get("/readyz", h.Ready)
+}
+
+// Health always responds 200 while the server is alive, the report tells whether it is degraded.
+func (h *HealthHandler) Health(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.healthLogic.Health())
+}
+
+// Ready responds 503 when the server is not ready.
+func (h *HealthHandler) Ready(c *fiber.Ctx) error {
+	report, ready := h.healthLogic.Ready()
+	if !ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+	return c.Status(fiber.StatusOK).JSON(report)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/dongwlin/elf-aid-magic/internal/operator"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type InputHandler struct {
+	logger     *zap.Logger
+	inputLogic *logic.InputLogic
+}
+
+func NewInputHandler(logger *zap.Logger, inputLogic *logic.InputLogic) *InputHandler {
+	return &InputHandler{
+		logger:     logger,
+		inputLogic: inputLogic,
+	}
+}
+
+func (h *InputHandler) Register(r fiber.Router) {
+	r.Post("/input/:id", h.PostInput)
+}
+
+type PostInputRequest struct {
+	operator.Input
+	Force bool `json:"force"`
+}
+
+func (h *InputHandler) PostInput(c *fiber.Ctx) error {
+	var req PostInputRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body."})
+	}
+
+	err := h.inputLogic.PostInput(c.Params("id"), req.Input, req.Force, "api "+c.IP())
+	if err != nil {
+		return c.Status(inputErrorStatus(err)).JSON(fiber.Map{"message": logic.InputErrorMessage(err)})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{"message": "Success"})
+}
+
+func inputErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, logic.ErrOperatorNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(err, operator.ErrInputDisabled):
+		return fiber.StatusForbidden
+	case errors.Is(err, operator.ErrInvalidInput):
+		return fiber.StatusBadRequest
+	case errors.Is(err, operator.ErrRunOwnsController), errors.Is(err, operator.ErrNotConnected):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
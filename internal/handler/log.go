@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/dongwlin/elf-aid-magic/internal/logquery"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type LogHandler struct {
+	logger   *zap.Logger
+	logLogic *logic.LogLogic
+}
+
+func NewLogHandler(logger *zap.Logger, logLogic *logic.LogLogic) *LogHandler {
+	return &LogHandler{
+		logger:   logger,
+		logLogic: logLogic,
+	}
+}
+
+func (h *LogHandler) Register(r fiber.Router) {
+	r.Get("/logs", h.GetLogs)
+	r.Get("/log/level", h.GetLevel)
+	r.Put("/log/level", h.SetLevel)
+}
+
+type LogLevelResponse struct {
+	Level string `json:"level"`
+}
+
+func (h *LogHandler) GetLevel(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(LogLevelResponse{
+		Level: h.logLogic.Level(),
+	})
+}
+
+type SetLogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+func (h *LogHandler) SetLevel(c *fiber.Ctx) error {
+	var req SetLogLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid request body."})
+	}
+
+	if err := h.logLogic.SetLevel(req.Level, "api "+c.IP()); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Unknown log level, it must be one of debug, info, warn, error and fatal."})
+	}
+	return c.Status(fiber.StatusOK).JSON(LogLevelResponse{
+		Level: h.logLogic.Level(),
+	})
+}
+
+// GetLogs supports the query parameters since, until (RFC 3339), level, tasker_id, contains and limit.
+// The logs are the lines of the log file, oldest first.
+func (h *LogHandler) GetLogs(c *fiber.Ctx) error {
+	query := logquery.Query{
+		Level:    c.Query("level"),
+		TaskerID: c.Query("tasker_id"),
+		Contains: c.Query("contains"),
+		Limit:    c.QueryInt("limit", 200),
+	}
+	if _, ok := logger.LookupLevel(query.Level); query.Level != "" && !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid level."})
+	}
+	var err error
+	if since := c.Query("since"); since != "" {
+		if query.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid since."})
+		}
+	}
+	if until := c.Query("until"); until != "" {
+		if query.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid until."})
+		}
+	}
+
+	entries, err := h.logLogic.Search(query)
+	if err != nil {
+		h.logger.Error("failed to search logs",
+			zap.Error(err),
+		)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Failed to search logs."})
+	}
+	logs := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		logs = append(logs, entry.Raw)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"logs": logs,
+	})
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"bytes"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type MetricsHandler struct {
+	logger       *zap.Logger
+	metricsLogic *logic.MetricsLogic
+}
+
+func NewMetricsHandler(logger *zap.Logger, metricsLogic *logic.MetricsLogic) *MetricsHandler {
+	return &MetricsHandler{
+		logger:       logger,
+		metricsLogic: metricsLogic,
+	}
+}
+
+func (h *MetricsHandler) Register(r fiber.Router) {
+	r.Get("/metrics", h.Metrics)
+}
+
+// Metrics responds the metrics in the Prometheus text format.
+func (h *MetricsHandler) Metrics(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	if err := h.metricsLogic.Write(&buf); err != nil {
+		h.logger.Error("failed to write metrics",
+			zap.Error(err),
+		)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Failed to write metrics."})
+	}
+	c.Set(fiber.HeaderContentType, h.metricsLogic.ContentType())
+	return c.Status(fiber.StatusOK).Send(buf.Bytes())
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type RunHandler struct {
+	logger   *zap.Logger
+	runLogic *logic.RunLogic
+}
+
+func NewRunHandler(logger *zap.Logger, runLogic *logic.RunLogic) *RunHandler {
+	return &RunHandler{
+		logger:   logger,
+		runLogic: runLogic,
+	}
+}
+
+func (h *RunHandler) Register(r fiber.Router) {
+	runs := r.Group("/runs")
+	runs.Get("/", h.GetRuns)
+	runs.Get("/:id", h.GetRun)
+}
+
+// GetRuns supports the query parameters tasker_id, trigger, status, since, until (RFC 3339) and limit.
+func (h *RunHandler) GetRuns(c *fiber.Ctx) error {
+	query := history.Query{
+		TaskerID: c.Query("tasker_id"),
+		Trigger:  history.Trigger(c.Query("trigger")),
+		Status:   c.Query("status"),
+		Limit:    c.QueryInt("limit", 50),
+	}
+	var err error
+	if since := c.Query("since"); since != "" {
+		if query.Since, err = time.Parse(time.RFC3339, since); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid since."})
+		}
+	}
+	if until := c.Query("until"); until != "" {
+		if query.Until, err = time.Parse(time.RFC3339, until); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"message": "Invalid until."})
+		}
+	}
+
+	runs, err := h.runLogic.GetRuns(query)
+	if err != nil {
+		h.logger.Error("failed to query runs",
+			zap.Error(err),
+		)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Failed to query runs."})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"runs": runs,
+	})
+}
+
+func (h *RunHandler) GetRun(c *fiber.Ctx) error {
+	id := c.Params("id")
+	runs, err := h.runLogic.GetRun(id)
+	if err != nil {
+		h.logger.Error("failed to query run",
+			zap.String("id", id),
+			zap.Error(err),
+		)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"message": "Failed to query run."})
+	}
+	if len(runs) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Run not found."})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"runs": runs,
+	})
+}
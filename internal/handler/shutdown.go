@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"strings"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type ShutdownHandler struct {
+	logger        *zap.Logger
+	shutdownLogic *logic.ShutdownLogic
+}
+
+func NewShutdownHandler(logger *zap.Logger, shutdownLogic *logic.ShutdownLogic) *ShutdownHandler {
+	return &ShutdownHandler{
+		logger:        logger,
+		shutdownLogic: shutdownLogic,
+	}
+}
+
+func (h *ShutdownHandler) Register(r fiber.Router) {
+	r.Post("/shutdown", h.Shutdown)
+}
+
+// Token returns the token required by /api/shutdown.
+func (h *ShutdownHandler) Token() string {
+	return h.shutdownLogic.Token()
+}
+
+// SetShutdownFunc sets the func called by an authorized /api/shutdown.
+func (h *ShutdownHandler) SetShutdownFunc(shutdownFunc func()) {
+	h.shutdownLogic.SetShutdownFunc(shutdownFunc)
+}
+
+// MarkShuttingDown makes /readyz report not ready.
+func (h *ShutdownHandler) MarkShuttingDown() {
+	h.shutdownLogic.MarkShuttingDown()
+}
+
+// Shutdown requires the header "Authorization: Bearer <token>".
+func (h *ShutdownHandler) Shutdown(c *fiber.Ctx) error {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || !h.shutdownLogic.Authorize(token) {
+		h.logger.Warn("unauthorized shutdown request",
+			zap.String("ip", c.IP()),
+		)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"message": "Unauthorized."})
+	}
+
+	if !h.shutdownLogic.Shutdown() {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "Shutdown is not available."})
+	}
+	h.logger.Info("shutdown requested",
+		zap.String("ip", c.IP()),
+	)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"message": "Shutting down."})
+}
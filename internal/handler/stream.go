@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logic"
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/mjpeg"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+type StreamHandler struct {
+	logger      *zap.Logger
+	streamLogic *logic.StreamLogic
+}
+
+func NewStreamHandler(logger *zap.Logger, streamLogic *logic.StreamLogic) *StreamHandler {
+	return &StreamHandler{
+		logger:      logger,
+		streamLogic: streamLogic,
+	}
+}
+
+func (h *StreamHandler) Register(r fiber.Router) {
+	r.Get("/stream/:id", h.Stream)
+}
+
+// Stream sends the screen of the operator as an MJPEG stream at the fps query parameter,
+// until the client disconnects or the operator stops.
+func (h *StreamHandler) Stream(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := h.streamLogic.GetFrame(id); err != nil {
+		if errors.Is(err, logic.ErrOperatorNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"message": "Operator not found."})
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"message": "No screen available, the operator is not running."})
+	}
+
+	interval := time.Second / time.Duration(h.streamLogic.FPS(c.QueryInt("fps")))
+	quality := h.streamLogic.Quality()
+
+	c.Set(fiber.HeaderContentType, mjpeg.ContentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			frame, err := h.streamLogic.GetFrame(id)
+			if err != nil {
+				return
+			}
+			if err := mjpeg.WriteFrame(w, frame, quality); err != nil {
+				h.logger.Error("failed to encode frame",
+					zap.String("tasker id", id),
+					zap.Error(err),
+				)
+				return
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+			<-ticker.C
+		}
+	})
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/dongwlin/elf-aid-magic/internal/logic"
 	"github.com/dongwlin/elf-aid-magic/internal/message"
+	"github.com/dongwlin/elf-aid-magic/internal/metrics"
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
@@ -38,6 +39,8 @@ func (h *WebSocketHandler) Register(r fiber.Router) {
 func (h *WebSocketHandler) WebSocket(c *websocket.Conn) {
 	h.addConnection(c)
 	defer h.removeConnection(c)
+	h.webSocketLogic.AddConnection(c)
+	defer h.webSocketLogic.RemoveConnection(c)
 
 	for {
 		if err := h.handleConnection(c); err != nil {
@@ -54,12 +57,14 @@ func (h *WebSocketHandler) addConnection(c *websocket.Conn) {
 	h.connMutex.Lock()
 	defer h.connMutex.Unlock()
 	h.connections[c] = true
+	metrics.WebSocketClients.Set(float64(len(h.connections)))
 }
 
 func (h *WebSocketHandler) removeConnection(c *websocket.Conn) {
 	h.connMutex.Lock()
 	defer h.connMutex.Unlock()
 	delete(h.connections, c)
+	metrics.WebSocketClients.Set(float64(len(h.connections)))
 	c.Close()
 }
 
@@ -97,6 +102,7 @@ func (h *WebSocketHandler) SendMessage(conn *websocket.Conn, msgType int, messag
 			)
 			conn.Close()
 			delete(h.connections, conn)
+			metrics.WebSocketClients.Set(float64(len(h.connections)))
 			return err
 		}
 	}
@@ -116,4 +122,5 @@ func (h *WebSocketHandler) BroadcastMessage(msgType int, message []byte) {
 			delete(h.connections, conn)
 		}
 	}
+	metrics.WebSocketClients.Set(float64(len(h.connections)))
 }
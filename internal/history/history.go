@@ -0,0 +1,207 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Trigger string
+
+// Trigger
+const (
+	TriggerCLI       Trigger = "cli"
+	TriggerWebSocket Trigger = "websocket"
+	TriggerScheduler Trigger = "scheduler"
+)
+
+// Status of a run or a task.
+const (
+	StatusRunning     = "running"
+	StatusSuccess     = "success"
+	StatusFailed      = "failed"
+	StatusInterrupted = "interrupted"
+)
+
+// Key and messages of the logs of a run, from which tools/json_converter rebuilds the runs.
+// Every log of a run carries its id under LogKeyRunID.
+const (
+	LogKeyRunID       = "run id"
+	LogMsgStartRun    = "start run"
+	LogMsgRunTask     = "run task"
+	LogMsgRetryTask   = "retry task after recovering the controller"
+	LogMsgTaskSuccess = "success to complete the task"
+	LogMsgTaskFailed  = "failed to complete the task"
+	LogMsgFinishRun   = "finish run"
+)
+
+type TaskResult struct {
+	Index  int    `json:"index"`
+	Entry  string `json:"entry"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// DebugBundle is the name of the debug bundle saved when the task failed.
+	DebugBundle string    `json:"debug_bundle,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	EndedAt     time.Time `json:"ended_at"`
+}
+
+// Run is the record of one run of a tasker.
+// A resumed run keeps the ID of the interrupted one and is recorded as a separate record with Resumed set.
+// An ad hoc run runs a single entry given on demand instead of the tasks of the tasker.
+type Run struct {
+	ID        string       `json:"id"`
+	TaskerID  string       `json:"tasker_id"`
+	Trigger   Trigger      `json:"trigger"`
+	Resumed   bool         `json:"resumed"`
+	AdHoc     bool         `json:"ad_hoc,omitempty"`
+	Status    string       `json:"status"`
+	Error     string       `json:"error,omitempty"`
+	StartedAt time.Time    `json:"started_at"`
+	EndedAt   time.Time    `json:"ended_at"`
+	Tasks     []TaskResult `json:"tasks"`
+}
+
+// Store persists runs as JSON lines in a single file.
+type Store struct {
+	path  string
+	mutex sync.Mutex
+}
+
+func NewStore(path string) *Store {
+	return &Store{
+		path: path,
+	}
+}
+
+// DefaultPath returns data/history/runs.jsonl next to the executable.
+func DefaultPath() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "data", "history", "runs.jsonl"), nil
+}
+
+// NewDefaultStore returns the store at DefaultPath, or at the same path relative to the
+// working directory if the executable can not be located.
+func NewDefaultStore() *Store {
+	path, err := DefaultPath()
+	if err != nil {
+		path = filepath.Join("data", "history", "runs.jsonl")
+	}
+	return NewStore(path)
+}
+
+func (s *Store) Append(run *Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Query filters runs. Zero values match everything.
+type Query struct {
+	TaskerID string
+	Trigger  Trigger
+	Status   string
+	Since    time.Time
+	Until    time.Time
+	Limit    int
+}
+
+func (q *Query) match(run *Run) bool {
+	if q.TaskerID != "" && run.TaskerID != q.TaskerID {
+		return false
+	}
+	if q.Trigger != "" && run.Trigger != q.Trigger {
+		return false
+	}
+	if q.Status != "" && run.Status != q.Status {
+		return false
+	}
+	if !q.Since.IsZero() && run.StartedAt.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && run.StartedAt.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// Query returns the runs matched by q, newest first.
+// Lines which can not be decoded are skipped.
+func (s *Store) Query(q Query) ([]Run, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, err := os.Open(s.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return []Run{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var runs []Run
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var run Run
+		if err := json.Unmarshal(scanner.Bytes(), &run); err != nil {
+			continue
+		}
+		if q.match(&run) {
+			runs = append(runs, run)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]Run, 0, len(runs))
+	for i := len(runs) - 1; i >= 0; i-- {
+		if q.Limit > 0 && len(result) >= q.Limit {
+			break
+		}
+		result = append(result, runs[i])
+	}
+	return result, nil
+}
+
+// Get returns the records of the run with id, newest first.
+// A resumed run has one record per attempt.
+func (s *Store) Get(id string) ([]Run, error) {
+	runs, err := s.Query(Query{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Run, 0, 1)
+	for _, run := range runs {
+		if run.ID == id {
+			result = append(result, run)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history", "runs.jsonl")
+	store := NewStore(path)
+
+	runs, err := store.Query(Query{})
+	require.NoError(t, err)
+	require.Empty(t, runs)
+
+	start := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	records := []Run{
+		{ID: "1", TaskerID: "a", Trigger: TriggerCLI, Status: StatusSuccess, StartedAt: start},
+		{ID: "2", TaskerID: "b", Trigger: TriggerWebSocket, Status: StatusFailed, StartedAt: start.Add(time.Hour)},
+		{ID: "2", TaskerID: "b", Trigger: TriggerWebSocket, Resumed: true, Status: StatusSuccess, StartedAt: start.Add(2 * time.Hour)},
+		{ID: "3", TaskerID: "a", Trigger: TriggerScheduler, Status: StatusInterrupted, StartedAt: start.Add(3 * time.Hour)},
+	}
+	for i := range records {
+		require.NoError(t, store.Append(&records[i]))
+	}
+
+	// A broken line must not hide the other records.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString("{broken\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	testCases := []struct {
+		Name      string
+		Query     Query
+		ExpectIDs []string
+	}{
+		{"All Newest First", Query{}, []string{"3", "2", "2", "1"}},
+		{"Limit", Query{Limit: 2}, []string{"3", "2"}},
+		{"Tasker", Query{TaskerID: "a"}, []string{"3", "1"}},
+		{"Trigger", Query{Trigger: TriggerWebSocket}, []string{"2", "2"}},
+		{"Status", Query{Status: StatusSuccess}, []string{"2", "1"}},
+		{"Time Range", Query{Since: start.Add(time.Hour), Until: start.Add(2 * time.Hour)}, []string{"2", "2"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			runs, err := store.Query(tc.Query)
+			require.NoError(t, err)
+			ids := make([]string, 0, len(runs))
+			for _, run := range runs {
+				ids = append(ids, run.ID)
+			}
+			require.Equal(t, tc.ExpectIDs, ids)
+		})
+	}
+
+	attempts, err := store.Get("2")
+	require.NoError(t, err)
+	require.Len(t, attempts, 2)
+	require.True(t, attempts[0].Resumed)
+}
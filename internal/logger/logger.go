@@ -10,44 +10,101 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// FilePath is the path of the JSON log file, relative to the executable.
+const FilePath = "debug/log.jsonl"
+
+// DefaultFile returns the path of the JSON log file next to the executable.
+func DefaultFile() (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(exePath), filepath.FromSlash(FilePath)), nil
+}
+
+// TaskerIDKey is the key of the field added to the logs of a tasker by ForTasker.
+const TaskerIDKey = "tasker_id"
+
+// New returns a logger writing to the sinks enabled in the log config:
+// the console (stderr), debug/log.jsonl and one debug/taskers/<tasker id>.jsonl per tasker.
 func New(conf *config.Config) *zap.Logger {
+	return NewWithLevel(conf, NewLevel(conf))
+}
+
+// NewLevel returns the level of the log config, which can be changed at runtime.
+func NewLevel(conf *config.Config) zap.AtomicLevel {
+	return zap.NewAtomicLevelAt(ParseLevel(conf.Log.Level))
+}
+
+// NewWithLevel is New with the level of every sink controlled by level.
+func NewWithLevel(conf *config.Config, level zap.AtomicLevel) *zap.Logger {
 	exePath, err := os.Executable()
 	if err != nil {
 		return nil
 	}
 	exeDir := filepath.Dir(exePath)
-	logPath := filepath.Join(exeDir, "debug", "log.jsonl")
-	hook := lumberjack.Logger{
-		Filename:   logPath,
+
+	var cores []zapcore.Core
+	if conf.Log.Console {
+		cores = append(cores, zapcore.NewCore(
+			getConsoleEncoder(conf.Log.Color && isTerminal(os.Stderr)),
+			zapcore.Lock(os.Stderr),
+			level,
+		))
+	}
+	if conf.Log.File {
+		logPath := filepath.Join(exeDir, filepath.FromSlash(FilePath))
+		cores = append(cores, zapcore.NewCore(
+			getJsonEncoder(),
+			zapcore.AddSync(NewRotatingWriter(conf, logPath)),
+			level,
+		))
+	}
+	if conf.Log.TaskerFiles {
+		cores = append(cores, newTaskerCore(conf, filepath.Join(exeDir, "debug", "taskers"), getJsonEncoder(), level))
+	}
+	return zap.New(zapcore.NewTee(cores...), zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
+}
+
+// ForTasker returns a logger adding the tasker id to every log, which routes them to the file of the tasker.
+func ForTasker(l *zap.Logger, taskerID string) *zap.Logger {
+	return l.With(zap.String(TaskerIDKey, taskerID))
+}
+
+// NewRotatingWriter returns a writer to the file at path, rotated with the log settings.
+func NewRotatingWriter(conf *config.Config, path string) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   path,
 		MaxSize:    conf.Log.MaxSize,
 		MaxBackups: conf.Log.MaxBackups,
 		MaxAge:     conf.Log.MaxAge,
 		Compress:   conf.Log.Compress,
 	}
+}
 
-	encoder := getJsonEncoder()
-	core := zapcore.NewCore(
-		encoder,
-		zapcore.AddSync(&hook),
-		getLevel(conf.Log.Level),
-	)
-	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zap.ErrorLevel))
+// ParseLevel returns the level named level, info if the name is unknown.
+func ParseLevel(level string) zapcore.Level {
+	if l, ok := LookupLevel(level); ok {
+		return l
+	}
+	return zap.InfoLevel
 }
 
-func getLevel(level string) zapcore.Level {
+// LookupLevel returns the level named level, one of debug, info, warn, error and fatal.
+func LookupLevel(level string) (zapcore.Level, bool) {
 	switch level {
 	case "debug":
-		return zap.DebugLevel
+		return zap.DebugLevel, true
 	case "info":
-		return zap.InfoLevel
+		return zap.InfoLevel, true
 	case "warn":
-		return zap.WarnLevel
+		return zap.WarnLevel, true
 	case "error":
-		return zap.ErrorLevel
+		return zap.ErrorLevel, true
 	case "fatal":
-		return zap.FatalLevel
+		return zap.FatalLevel, true
 	default:
-		return zap.InfoLevel
+		return zap.InfoLevel, false
 	}
 }
 
@@ -67,6 +124,24 @@ func getLumberjackLogger(conf *config.Config) (lumberjack.Logger, error) {
 	}, nil
 }
 
+func getConsoleEncoder(color bool) zapcore.Encoder {
+	conf := zap.NewDevelopmentEncoderConfig()
+	conf.EncodeTime = zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05.000")
+	if color {
+		conf.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	return zapcore.NewConsoleEncoder(conf)
+}
+
+// isTerminal reports whether f is a terminal, colors are only written to terminals.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 func getJsonEncoder() zapcore.Encoder {
 	conf := zap.NewProductionEncoderConfig()
 	conf.TimeKey = "time"
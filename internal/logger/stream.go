@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// streamQueueSize is the number of entries waiting for the listeners of a stream, newer entries are dropped beyond it.
+const streamQueueSize = 1024
+
+// Entry is a log entry sent to the listeners of a Stream.
+type Entry struct {
+	Time     time.Time              `json:"time"`
+	Level    zapcore.Level          `json:"level"`
+	TaskerID string                 `json:"tasker_id,omitempty"`
+	Message  string                 `json:"msg"`
+	Caller   string                 `json:"caller,omitempty"`
+	Fields   map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Stream keeps the recent log entries and passes every new entry to its listeners.
+// Listeners are called from a single goroutine, so that they may log without blocking the logger.
+type Stream struct {
+	size      int
+	entries   []Entry
+	next      int
+	listeners []func(Entry)
+	queue     chan Entry
+	mutex     sync.Mutex
+}
+
+// NewStream returns a stream keeping the last size entries.
+func NewStream(size int) *Stream {
+	s := &Stream{
+		size:  max(size, 0),
+		queue: make(chan Entry, streamQueueSize),
+	}
+	go s.dispatch()
+	return s
+}
+
+// Attach returns l also writing the entries at level or above to the stream.
+func (s *Stream) Attach(l *zap.Logger, level zapcore.LevelEnabler) *zap.Logger {
+	return l.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, &streamCore{LevelEnabler: level, stream: s})
+	}))
+}
+
+// StreamLevel enables the entries at streamLevel or above, and also the entries enabled by level
+// while it is lowered below streamLevel, e.g. switched to debug at runtime.
+func StreamLevel(streamLevel zapcore.Level, level zap.AtomicLevel) zapcore.LevelEnabler {
+	return zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return l >= min(streamLevel, level.Level())
+	})
+}
+
+func (s *Stream) AddListener(listener func(Entry)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+// Recent returns the kept entries, oldest first.
+func (s *Stream) Recent() []Entry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entries := make([]Entry, 0, len(s.entries))
+	if len(s.entries) == s.size {
+		entries = append(entries, s.entries[s.next:]...)
+		entries = append(entries, s.entries[:s.next]...)
+	} else {
+		entries = append(entries, s.entries...)
+	}
+	return entries
+}
+
+func (s *Stream) add(entry Entry) {
+	s.mutex.Lock()
+	if s.size > 0 {
+		if len(s.entries) < s.size {
+			s.entries = append(s.entries, entry)
+		} else {
+			s.entries[s.next] = entry
+			s.next = (s.next + 1) % s.size
+		}
+	}
+	s.mutex.Unlock()
+
+	select {
+	case s.queue <- entry:
+	default:
+	}
+}
+
+func (s *Stream) dispatch() {
+	for entry := range s.queue {
+		s.mutex.Lock()
+		listeners := make([]func(Entry), len(s.listeners))
+		copy(listeners, s.listeners)
+		s.mutex.Unlock()
+
+		for _, listener := range listeners {
+			listener(entry)
+		}
+	}
+}
+
+type streamCore struct {
+	zapcore.LevelEnabler
+	stream *Stream
+	fields []zapcore.Field
+}
+
+func (c *streamCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &streamCore{
+		LevelEnabler: c.LevelEnabler,
+		stream:       c.stream,
+		fields:       make([]zapcore.Field, 0, len(c.fields)+len(fields)),
+	}
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	return clone
+}
+
+func (c *streamCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *streamCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	e := Entry{
+		Time:    entry.Time,
+		Level:   entry.Level,
+		Message: entry.Message,
+		Fields:  enc.Fields,
+	}
+	if entry.Caller.Defined {
+		e.Caller = entry.Caller.TrimmedPath()
+	}
+	if id, ok := enc.Fields[TaskerIDKey].(string); ok {
+		e.TaskerID = id
+		delete(enc.Fields, TaskerIDKey)
+	}
+	if len(e.Fields) == 0 {
+		e.Fields = nil
+	}
+	c.stream.add(e)
+	return nil
+}
+
+func (c *streamCore) Sync() error {
+	return nil
+}
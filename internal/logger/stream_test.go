@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStream(t *testing.T) {
+	stream := NewStream(2)
+	received := make(chan Entry, 10)
+	stream.AddListener(func(entry Entry) {
+		received <- entry
+	})
+	l := stream.Attach(zap.NewNop(), zap.InfoLevel)
+
+	l.Debug("below level")
+	l.Info("first")
+	ForTasker(l, "a").Warn("second", zap.Int("attempt", 2))
+	l.Error("third")
+
+	recent := stream.Recent()
+	require.Len(t, recent, 2)
+	require.Equal(t, "second", recent[0].Message)
+	require.Equal(t, zapcore.WarnLevel, recent[0].Level)
+	require.Equal(t, "a", recent[0].TaskerID)
+	require.Equal(t, map[string]interface{}{"attempt": int64(2)}, recent[0].Fields)
+	require.Equal(t, "third", recent[1].Message)
+
+	var messages []string
+	for len(messages) < 3 {
+		select {
+		case entry := <-received:
+			messages = append(messages, entry.Message)
+		case <-time.After(time.Second):
+			t.Fatal("listener not called")
+		}
+	}
+	require.Equal(t, []string{"first", "second", "third"}, messages)
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"go.uber.org/zap/zapcore"
+)
+
+// taskerCore writes every log carrying a tasker id to the file of the tasker.
+// Logs without a tasker id are dropped, the other cores still write them.
+type taskerCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	files   *taskerFiles
+	// fields are kept until the tasker id is known, then core writes them.
+	fields []zapcore.Field
+	core   zapcore.Core
+}
+
+func newTaskerCore(conf *config.Config, dir string, encoder zapcore.Encoder, level zapcore.LevelEnabler) *taskerCore {
+	return &taskerCore{
+		LevelEnabler: level,
+		encoder:      encoder,
+		files: &taskerFiles{
+			conf:    conf,
+			dir:     dir,
+			writers: make(map[string]zapcore.WriteSyncer),
+		},
+	}
+}
+
+func (c *taskerCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := &taskerCore{
+		LevelEnabler: c.LevelEnabler,
+		encoder:      c.encoder,
+		files:        c.files,
+	}
+	if c.core != nil {
+		clone.core = c.core.With(fields)
+		return clone
+	}
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+	if id, ok := taskerID(fields); ok {
+		clone.core = c.newCore(id).With(clone.fields)
+		clone.fields = nil
+	}
+	return clone
+}
+
+func (c *taskerCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *taskerCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	if c.core != nil {
+		return c.core.Write(entry, fields)
+	}
+	id, ok := taskerID(fields)
+	if !ok {
+		return nil
+	}
+	return c.newCore(id).With(c.fields).Write(entry, fields)
+}
+
+func (c *taskerCore) Sync() error {
+	return c.files.sync()
+}
+
+func (c *taskerCore) newCore(id string) zapcore.Core {
+	return zapcore.NewCore(c.encoder, c.files.get(id), c.LevelEnabler)
+}
+
+func taskerID(fields []zapcore.Field) (string, bool) {
+	for _, field := range fields {
+		if field.Key == TaskerIDKey && field.Type == zapcore.StringType && field.String != "" {
+			return field.String, true
+		}
+	}
+	return "", false
+}
+
+// taskerFiles opens one rotated file per tasker on first use.
+type taskerFiles struct {
+	conf    *config.Config
+	dir     string
+	writers map[string]zapcore.WriteSyncer
+	mutex   sync.Mutex
+}
+
+func (f *taskerFiles) get(id string) zapcore.WriteSyncer {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	w, exists := f.writers[id]
+	if !exists {
+		path := filepath.Join(f.dir, taskerFileName(id))
+		w = zapcore.Lock(zapcore.AddSync(NewRotatingWriter(f.conf, path)))
+		f.writers[id] = w
+	}
+	return w
+}
+
+func (f *taskerFiles) sync() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for _, w := range f.writers {
+		if err := w.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// taskerFileName keeps the tasker id safe to use as a file name.
+func taskerFileName(id string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, id) + ".jsonl"
+}
@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestTaskerCore(t *testing.T) {
+	dir := t.TempDir()
+	conf := &config.Config{Log: &config.LogConfig{MaxSize: 1}}
+	l := zap.New(newTaskerCore(conf, dir, getJsonEncoder(), zap.InfoLevel))
+
+	l.Info("no tasker")
+	ForTasker(l.With(zap.String("run id", "r1")), "a").Info("from a", zap.Int("n", 1))
+	l.Info("from b", zap.String(TaskerIDKey, "b/../b"))
+	ForTasker(l, "a").Debug("below level")
+	require.NoError(t, l.Sync())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	a, err := os.ReadFile(filepath.Join(dir, "a.jsonl"))
+	require.NoError(t, err)
+	require.Equal(t, 1, strings.Count(string(a), "\n"))
+	require.Contains(t, string(a), `"msg":"from a"`)
+	require.Contains(t, string(a), `"run id":"r1"`)
+	require.Contains(t, string(a), `"tasker_id":"a"`)
+
+	b, err := os.ReadFile(filepath.Join(dir, "b____b.jsonl"))
+	require.NoError(t, err)
+	require.Contains(t, string(b), `"msg":"from b"`)
+}
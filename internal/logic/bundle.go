@@ -0,0 +1,30 @@
+package logic
+
+import (
+	"io"
+
+	"github.com/dongwlin/elf-aid-magic/internal/debugbundle"
+)
+
+type BundleLogic struct {
+	store *debugbundle.Store
+}
+
+func NewBundleLogic() *BundleLogic {
+	// Retention only applies when the operators save bundles.
+	return &BundleLogic{
+		store: debugbundle.NewDefaultStore(0),
+	}
+}
+
+func (l *BundleLogic) GetBundles() ([]debugbundle.Detail, error) {
+	return l.store.List()
+}
+
+func (l *BundleLogic) GetBundleFile(name, file string) (string, error) {
+	return l.store.File(name, file)
+}
+
+func (l *BundleLogic) WriteBundleZip(name string, w io.Writer) error {
+	return l.store.WriteZip(name, w)
+}
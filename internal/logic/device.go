@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"errors"
+
+	"github.com/MaaXYZ/maa-framework-go"
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+)
+
+var ErrAdbPathNotConfigured = errors.New("adb path is not configured")
+
+type DeviceLogic struct {
+	conf    *config.Config
+	toolkit *maa.Toolkit
+}
+
+func NewDeviceLogic(conf *config.Config) *DeviceLogic {
+	toolkit := maa.NewToolkit()
+	toolkit.ConfigInitOption("./", "{}")
+	return &DeviceLogic{
+		conf:    conf,
+		toolkit: toolkit,
+	}
+}
+
+type AdbDevice struct {
+	Serial           string   `json:"serial"`
+	Name             string   `json:"name"`
+	AdbPath          string   `json:"adb_path"`
+	ScreencapMethods []string `json:"screencap_methods"`
+	InputMethods     []string `json:"input_methods"`
+	Config           string   `json:"config"`
+}
+
+// FindAdbDevices lists the connected adb devices with their suggested screencap and input methods.
+// If adbPath is empty, MaaFramework searches the adb binaries of the known emulators.
+func (l *DeviceLogic) FindAdbDevices(adbPath string) []AdbDevice {
+	var found []*maa.AdbDevice
+	if adbPath == "" {
+		found = l.toolkit.FindAdbDevices()
+	} else {
+		found = l.toolkit.FindAdbDevices(adbPath)
+	}
+
+	devices := make([]AdbDevice, 0, len(found))
+	for _, device := range found {
+		devices = append(devices, AdbDevice{
+			Serial:           device.Address,
+			Name:             device.Name,
+			AdbPath:          device.AdbPath,
+			ScreencapMethods: config.AdbScreencapMethodNames(config.AdbScreencapMethod(device.ScreencapMethod)),
+			InputMethods:     config.AdbInputMethodNames(config.AdbInputMethod(device.InputMethod)),
+			Config:           device.Config,
+		})
+	}
+	return devices
+}
+
+// FindConfiguredAdbDevices is FindAdbDevices for remote callers, which must not run an arbitrary binary.
+// adbPath must be empty or one of the adb paths of the config.
+func (l *DeviceLogic) FindConfiguredAdbDevices(adbPath string) ([]AdbDevice, error) {
+	if adbPath != "" && !l.adbPathConfigured(adbPath) {
+		return nil, ErrAdbPathNotConfigured
+	}
+	return l.FindAdbDevices(adbPath), nil
+}
+
+func (l *DeviceLogic) adbPathConfigured(adbPath string) bool {
+	if l.conf.AdbPath == adbPath {
+		return true
+	}
+	for _, tasker := range l.conf.Taskers {
+		if tasker != nil && tasker.AdbDevice.AdbPath == adbPath {
+			return true
+		}
+	}
+	return false
+}
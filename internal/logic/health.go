@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/operator"
+)
+
+// Statuses of a health report.
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+	HealthStatusReady    = "ready"
+	HealthStatusNotReady = "not_ready"
+)
+
+// SchedulerStatus reports the scheduler. There is no scheduler yet, runs are only triggered by the cli and the web ui.
+type SchedulerStatus struct {
+	Enabled bool   `json:"enabled"`
+	Status  string `json:"status"`
+}
+
+type HealthReport struct {
+	Status       string            `json:"status"`
+	ShuttingDown bool              `json:"shutting_down"`
+	Scheduler    SchedulerStatus   `json:"scheduler"`
+	Operators    []operator.Status `json:"operators"`
+	Time         time.Time         `json:"time"`
+}
+
+type HealthLogic struct {
+	operatorManager *operator.Manager
+	shutdownLogic   *ShutdownLogic
+}
+
+func NewHealthLogic(om *operator.Manager, shutdownLogic *ShutdownLogic) *HealthLogic {
+	return &HealthLogic{
+		operatorManager: om,
+		shutdownLogic:   shutdownLogic,
+	}
+}
+
+// Health reports degraded if an operator has failed and not recovered since, by a successful run or a new connection,
+// or if a running operator lost its resource or its controller.
+func (l *HealthLogic) Health() HealthReport {
+	report := l.report()
+	report.Status = HealthStatusOK
+	for _, status := range report.Operators {
+		if status.LastError != "" || !operatorReady(status) {
+			report.Status = HealthStatusDegraded
+			break
+		}
+	}
+	return report
+}
+
+// Ready reports not ready while the server is shutting down, or while a running operator can not run tasks.
+// Idle operators do not affect readiness, they load their resource and connect when a run starts.
+func (l *HealthLogic) Ready() (HealthReport, bool) {
+	report := l.report()
+	ready := !report.ShuttingDown
+	for _, status := range report.Operators {
+		if !operatorReady(status) {
+			ready = false
+			break
+		}
+	}
+	report.Status = HealthStatusNotReady
+	if ready {
+		report.Status = HealthStatusReady
+	}
+	return report, ready
+}
+
+func (l *HealthLogic) report() HealthReport {
+	operators := l.operatorManager.Operators()
+	statuses := make([]operator.Status, 0, len(operators))
+	for _, o := range operators {
+		statuses = append(statuses, o.Status())
+	}
+	return HealthReport{
+		ShuttingDown: l.shutdownLogic.ShuttingDown(),
+		Scheduler: SchedulerStatus{
+			Enabled: false,
+			Status:  "disabled",
+		},
+		Operators: statuses,
+		Time:      time.Now(),
+	}
+}
+
+func operatorReady(status operator.Status) bool {
+	return !status.Running || (status.ResourceLoaded && status.Connected)
+}
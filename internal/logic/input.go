@@ -0,0 +1,45 @@
+package logic
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/dongwlin/elf-aid-magic/internal/operator"
+)
+
+type InputLogic struct {
+	operatorManager *operator.Manager
+}
+
+func NewInputLogic(om *operator.Manager) *InputLogic {
+	return &InputLogic{
+		operatorManager: om,
+	}
+}
+
+func (l *InputLogic) PostInput(taskerID string, input operator.Input, force bool, source string) error {
+	o, exists := l.operatorManager.GetOperatorByID(taskerID)
+	if !exists {
+		return ErrOperatorNotFound
+	}
+	return o.PostInput(input, force, source)
+}
+
+// InputErrorMessage returns the message reported to the client for an error of PostInput.
+func InputErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, ErrOperatorNotFound):
+		return "Operator don't exists."
+	case errors.Is(err, operator.ErrInputDisabled):
+		return "Manual input is disabled, set server.manual_input to enable it."
+	case errors.Is(err, operator.ErrInvalidInput):
+		msg := err.Error()
+		return strings.ToUpper(msg[:1]) + msg[1:] + "."
+	case errors.Is(err, operator.ErrRunOwnsController):
+		return "A run owns the controller, set force to post anyway."
+	case errors.Is(err, operator.ErrNotConnected):
+		return "Controller is not connected."
+	default:
+		return "Failed to post input."
+	}
+}
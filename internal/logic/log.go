@@ -0,0 +1,52 @@
+package logic
+
+import (
+	"errors"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"github.com/dongwlin/elf-aid-magic/internal/logquery"
+	"go.uber.org/zap"
+)
+
+var ErrInvalidLogLevel = errors.New("invalid log level")
+
+type LogLogic struct {
+	logger *zap.Logger
+	level  zap.AtomicLevel
+}
+
+func NewLogLogic(logger *zap.Logger, level zap.AtomicLevel) *LogLogic {
+	return &LogLogic{
+		logger: logger,
+		level:  level,
+	}
+}
+
+func (l *LogLogic) Level() string {
+	return l.level.Level().String()
+}
+
+// SetLevel changes the level of every log sink until the server restarts, the config file is left as is.
+func (l *LogLogic) SetLevel(level, source string) error {
+	lvl, ok := logger.LookupLevel(level)
+	if !ok {
+		return ErrInvalidLogLevel
+	}
+	old := l.level.Level()
+	l.level.SetLevel(lvl)
+	l.logger.Warn("log level changed",
+		zap.String("from", old.String()),
+		zap.String("to", lvl.String()),
+		zap.String("source", source),
+	)
+	return nil
+}
+
+// Search returns the logs matched by query in debug/log.jsonl and its rotated backups, oldest first.
+func (l *LogLogic) Search(query logquery.Query) ([]logquery.Entry, error) {
+	path, err := logger.DefaultFile()
+	if err != nil {
+		return nil, err
+	}
+	return logquery.Search(path, query)
+}
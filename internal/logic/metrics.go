@@ -0,0 +1,21 @@
+package logic
+
+import (
+	"io"
+
+	"github.com/dongwlin/elf-aid-magic/internal/metrics"
+)
+
+type MetricsLogic struct{}
+
+func NewMetricsLogic() *MetricsLogic {
+	return &MetricsLogic{}
+}
+
+func (l *MetricsLogic) ContentType() string {
+	return metrics.ContentType
+}
+
+func (l *MetricsLogic) Write(w io.Writer) error {
+	return metrics.Write(w)
+}
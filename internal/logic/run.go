@@ -0,0 +1,23 @@
+package logic
+
+import (
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+)
+
+type RunLogic struct {
+	store *history.Store
+}
+
+func NewRunLogic() *RunLogic {
+	return &RunLogic{
+		store: history.NewDefaultStore(),
+	}
+}
+
+func (l *RunLogic) GetRuns(query history.Query) ([]history.Run, error) {
+	return l.store.Query(query)
+}
+
+func (l *RunLogic) GetRun(id string) ([]history.Run, error) {
+	return l.store.Get(id)
+}
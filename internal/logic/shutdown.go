@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"sync"
+	"sync/atomic"
+)
+
+type ShutdownLogic struct {
+	token        string
+	shutdownFunc func()
+	once         sync.Once
+	shuttingDown atomic.Bool
+}
+
+// NewShutdownLogic returns a logic with a random token, which the server shares with `eam stop` through the daemon directory.
+func NewShutdownLogic() *ShutdownLogic {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return &ShutdownLogic{
+		token: hex.EncodeToString(b),
+	}
+}
+
+func (l *ShutdownLogic) Token() string {
+	return l.token
+}
+
+func (l *ShutdownLogic) SetShutdownFunc(shutdownFunc func()) {
+	l.shutdownFunc = shutdownFunc
+}
+
+func (l *ShutdownLogic) Authorize(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(l.token)) == 1
+}
+
+// Shutdown calls the shutdown func once, later calls do nothing.
+func (l *ShutdownLogic) Shutdown() bool {
+	if l.shutdownFunc == nil {
+		return false
+	}
+	l.MarkShuttingDown()
+	l.once.Do(l.shutdownFunc)
+	return true
+}
+
+// MarkShuttingDown makes the server report not ready, whatever the reason it is shutting down.
+func (l *ShutdownLogic) MarkShuttingDown() {
+	l.shuttingDown.Store(true)
+}
+
+func (l *ShutdownLogic) ShuttingDown() bool {
+	return l.shuttingDown.Load()
+}
@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/operator"
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/mjpeg"
+)
+
+const maxStreamFPS = 30
+
+// boxLifetime is how long the box of a recognition is drawn over the stream.
+const boxLifetime = 3 * time.Second
+
+var (
+	ErrOperatorNotFound = errors.New("operator not found")
+	ErrNoScreen         = errors.New("no screen available")
+)
+
+var boxColor = color.RGBA{R: 255, A: 255}
+
+type StreamLogic struct {
+	conf            *config.Config
+	operatorManager *operator.Manager
+}
+
+func NewStreamLogic(conf *config.Config, om *operator.Manager) *StreamLogic {
+	return &StreamLogic{
+		conf:            conf,
+		operatorManager: om,
+	}
+}
+
+// FPS returns fps limited to 1 to 30, or the configured fps if fps is 0 or less.
+func (l *StreamLogic) FPS(fps int) int {
+	if fps <= 0 && l.conf.Stream != nil {
+		fps = l.conf.Stream.FPS
+	}
+	return min(max(fps, 1), maxStreamFPS)
+}
+
+func (l *StreamLogic) Quality() int {
+	if l.conf.Stream == nil || l.conf.Stream.Quality <= 0 {
+		return 75
+	}
+	return min(l.conf.Stream.Quality, 100)
+}
+
+// GetFrame returns the last screencap of the operator with the box of its recent recognition drawn over it.
+func (l *StreamLogic) GetFrame(taskerID string) (image.Image, error) {
+	o, exists := l.operatorManager.GetOperatorByID(taskerID)
+	if !exists {
+		return nil, ErrOperatorNotFound
+	}
+	img, box := o.Screen()
+	if img == nil {
+		return nil, ErrNoScreen
+	}
+	if box == nil || time.Since(box.Time) > boxLifetime {
+		return img, nil
+	}
+	return mjpeg.DrawRect(img, box.Box, boxColor, 3), nil
+}
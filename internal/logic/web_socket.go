@@ -4,7 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"sync"
 
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
 	"github.com/dongwlin/elf-aid-magic/internal/message"
 	"github.com/dongwlin/elf-aid-magic/internal/operator"
 	"github.com/gofiber/contrib/websocket"
@@ -18,17 +21,42 @@ type BroadcastMessageFunc func(msgType int, msg []byte)
 type WebSocketLogic struct {
 	logger               *zap.Logger
 	operatorManager      *operator.Manager
+	logStream            *logger.Stream
+	logLogic             *LogLogic
+	logFilters           map[*websocket.Conn]LogFilter
+	logMutex             sync.Mutex
+	launching            map[string]bool
+	launchMutex          sync.Mutex
 	sendMessageFunc      SendMessageFunc
 	broadcastMessageFunc BroadcastMessageFunc
-	ctx                  context.Context
-	cancel               context.CancelFunc
 }
 
-func NewWebSocketLogic(logger *zap.Logger, om *operator.Manager) *WebSocketLogic {
-	return &WebSocketLogic{
+func NewWebSocketLogic(logger *zap.Logger, om *operator.Manager, logStream *logger.Stream, logLogic *LogLogic) *WebSocketLogic {
+	l := &WebSocketLogic{
 		logger:          logger,
 		operatorManager: om,
+		logStream:       logStream,
+		logLogic:        logLogic,
+		logFilters:      make(map[*websocket.Conn]LogFilter),
+		launching:       make(map[string]bool),
 	}
+	om.AddEventListener(l.operatorEvent)
+	logStream.AddListener(l.logEntry)
+	return l
+}
+
+// AddConnection sends the recent logs to a new connection, which then receives every log until it sets a filter.
+func (l *WebSocketLogic) AddConnection(conn *websocket.Conn) {
+	l.logMutex.Lock()
+	l.logFilters[conn] = LogFilter{}
+	l.logMutex.Unlock()
+	l.replayLogs(conn, LogFilter{})
+}
+
+func (l *WebSocketLogic) RemoveConnection(conn *websocket.Conn) {
+	l.logMutex.Lock()
+	defer l.logMutex.Unlock()
+	delete(l.logFilters, conn)
 }
 
 func (l *WebSocketLogic) SetSendMessageFunc(sendMessageFunc SendMessageFunc) {
@@ -70,8 +98,18 @@ func (l *WebSocketLogic) handleRequest(conn *websocket.Conn, msgType int, msg *m
 	switch msg.Action {
 	case "start":
 		resp = l.start(msg)
+	case "resume":
+		resp = l.resume(msg)
+	case "run_entry":
+		resp = l.runEntry(msg)
 	case "stop":
 		resp = l.stop(msg)
+	case "input":
+		resp = l.input(conn, msg)
+	case "set_log_filter":
+		resp = l.setLogFilter(conn, msg)
+	case "set_log_level":
+		resp = l.setLogLevel(conn, msg)
 	default:
 		l.logger.Error("unknown request action",
 			zap.String("action", msg.Action),
@@ -144,6 +182,14 @@ type MessageStartRequestData struct {
 }
 
 func (l *WebSocketLogic) start(msg *message.Message) message.Message {
+	return l.startOperator(msg, false)
+}
+
+func (l *WebSocketLogic) resume(msg *message.Message) message.Message {
+	return l.startOperator(msg, true)
+}
+
+func (l *WebSocketLogic) startOperator(msg *message.Message, resume bool) message.Message {
 	var data MessageStartRequestData
 	if err := json.Unmarshal(msg.Data, &data); err != nil {
 		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to unserialize request data.", nil)
@@ -153,10 +199,60 @@ func (l *WebSocketLogic) start(msg *message.Message) message.Message {
 		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Tasker ID is empty.", nil)
 	}
 
-	operator, exists := l.operatorManager.GetOperatorByID(data.TaskerID)
+	run := func(o *operator.Operator) func(context.Context, history.Trigger) bool {
+		if resume {
+			return o.Resume
+		}
+		return o.Run
+	}
+	return l.launchOperator(msg, data.TaskerID, run)
+}
+
+type MessageRunEntryRequestData struct {
+	TaskerID string                 `json:"tasker_id"`
+	Entry    string                 `json:"entry"`
+	Param    map[string]interface{} `json:"param"`
+}
+
+// runEntry runs a single pipeline entry with the param of the request.
+func (l *WebSocketLogic) runEntry(msg *message.Message) message.Message {
+	var data MessageRunEntryRequestData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to unserialize request data.", nil)
+	}
+
+	if data.TaskerID == "" {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Tasker ID is empty.", nil)
+	}
+	if data.Entry == "" {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Entry is empty.", nil)
+	}
+
+	run := func(o *operator.Operator) func(context.Context, history.Trigger) bool {
+		return func(ctx context.Context, trigger history.Trigger) bool {
+			return o.RunEntry(ctx, trigger, data.Entry, data.Param)
+		}
+	}
+	return l.launchOperator(msg, data.TaskerID, run)
+}
+
+// launchOperator initializes and connects the operator, then runs it in the background with the run returned by runFunc.
+// The operator is launched at most once at a time, from its initialization until it is destroyed after the run.
+func (l *WebSocketLogic) launchOperator(msg *message.Message, taskerID string, runFunc func(o *operator.Operator) func(context.Context, history.Trigger) bool) message.Message {
+	operator, exists := l.operatorManager.GetOperatorByID(taskerID)
 	if !exists {
 		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Operator don't exists.", nil)
 	}
+	if !l.reserve(operator) {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Operator is already running.", nil)
+	}
+	launched := false
+	defer func() {
+		if !launched {
+			l.release(operator)
+		}
+	}()
+
 	if !operator.InitTasker() {
 		operator.Destroy()
 		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to init tasker.", nil)
@@ -177,11 +273,14 @@ func (l *WebSocketLogic) start(msg *message.Message) message.Message {
 		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to connect device.", nil)
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	l.ctx = ctx
-	l.cancel = cancel
+	run := runFunc(operator)
+	launched = true
 	go func() {
-		if operator.Run(l.ctx) {
+		defer l.release(operator)
+		// The run is stopped through StopRun, ctx only lives as long as the run.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if run(ctx, history.TriggerWebSocket) {
 			l.completed(operator.ID)
 		}
 		operator.Destroy()
@@ -190,13 +289,27 @@ func (l *WebSocketLogic) start(msg *message.Message) message.Message {
 
 }
 
+func (l *WebSocketLogic) reserve(o *operator.Operator) bool {
+	l.launchMutex.Lock()
+	defer l.launchMutex.Unlock()
+	if l.launching[o.ID] || o.Running() {
+		return false
+	}
+	l.launching[o.ID] = true
+	return true
+}
+
+func (l *WebSocketLogic) release(o *operator.Operator) {
+	l.launchMutex.Lock()
+	defer l.launchMutex.Unlock()
+	delete(l.launching, o.ID)
+}
+
 type MessageStopRequestData struct {
 	TaskerID string `json:"tasker_id"`
 }
 
 func (l *WebSocketLogic) stop(msg *message.Message) message.Message {
-	l.cancel()
-
 	var data MessageStopRequestData
 	if err := json.Unmarshal(msg.Data, &data); err != nil {
 		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to unserialize request data.", nil)
@@ -210,7 +323,33 @@ func (l *WebSocketLogic) stop(msg *message.Message) message.Message {
 	if !exists {
 		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Operator don't exists.", nil)
 	}
-	operator.Stop().Wait()
+	operator.StopRun()
+	return message.CreateResponse(l.logger, msg.Action, message.StatusSuccess, "Success", nil)
+}
+
+type MessageInputRequestData struct {
+	TaskerID string `json:"tasker_id"`
+	operator.Input
+	Force bool `json:"force"`
+}
+
+func (l *WebSocketLogic) input(conn *websocket.Conn, msg *message.Message) message.Message {
+	var data MessageInputRequestData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to unserialize request data.", nil)
+	}
+
+	if data.TaskerID == "" {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Tasker ID is empty.", nil)
+	}
+
+	operator, exists := l.operatorManager.GetOperatorByID(data.TaskerID)
+	if !exists {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Operator don't exists.", nil)
+	}
+	if err := operator.PostInput(data.Input, data.Force, "websocket "+conn.RemoteAddr().String()); err != nil {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, InputErrorMessage(err), nil)
+	}
 	return message.CreateResponse(l.logger, msg.Action, message.StatusSuccess, "Success", nil)
 }
 
@@ -226,3 +365,99 @@ func (l *WebSocketLogic) completed(taskerID string) {
 	msgBytes := serializeMessage(l.logger, msg)
 	l.broadcastMessage(websocket.TextMessage, msgBytes)
 }
+
+// operatorEvent broadcasts the events of every operator, e.g. controller_switched.
+func (l *WebSocketLogic) operatorEvent(event operator.Event) {
+	msg := message.CreateEvent(l.logger, event.Name, event)
+	msgBytes := serializeMessage(l.logger, msg)
+	l.broadcastMessage(websocket.TextMessage, msgBytes)
+}
+
+// LogFilter selects the logs sent to a connection. Empty fields match every log.
+type LogFilter struct {
+	TaskerID string `json:"tasker_id"`
+	Level    string `json:"level"`
+}
+
+func (f LogFilter) Match(entry logger.Entry) bool {
+	if f.TaskerID != "" && entry.TaskerID != f.TaskerID {
+		return false
+	}
+	return f.Level == "" || entry.Level >= logger.ParseLevel(f.Level)
+}
+
+// setLogFilter replaces the log filter of the connection, then sends the recent logs matching it
+// before the response, so that the client can replace the logs it shows.
+func (l *WebSocketLogic) setLogFilter(conn *websocket.Conn, msg *message.Message) message.Message {
+	var filter LogFilter
+	if err := json.Unmarshal(msg.Data, &filter); err != nil {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to unserialize request data.", nil)
+	}
+	if _, ok := logger.LookupLevel(filter.Level); filter.Level != "" && !ok {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Unknown log level.", nil)
+	}
+
+	l.logMutex.Lock()
+	l.logFilters[conn] = filter
+	l.logMutex.Unlock()
+	l.replayLogs(conn, filter)
+	return message.CreateResponse(l.logger, msg.Action, message.StatusSuccess, "Success", nil)
+}
+
+func (l *WebSocketLogic) replayLogs(conn *websocket.Conn, filter LogFilter) {
+	for _, entry := range l.logStream.Recent() {
+		if !filter.Match(entry) {
+			continue
+		}
+		if err := l.sendMessage(conn, websocket.TextMessage, logMessage(l.logger, entry)); err != nil {
+			return
+		}
+	}
+}
+
+// logEntry sends a log event to every connection whose filter matches entry.
+func (l *WebSocketLogic) logEntry(entry logger.Entry) {
+	l.logMutex.Lock()
+	var conns []*websocket.Conn
+	for conn, filter := range l.logFilters {
+		if filter.Match(entry) {
+			conns = append(conns, conn)
+		}
+	}
+	l.logMutex.Unlock()
+	if len(conns) == 0 {
+		return
+	}
+
+	msgBytes := logMessage(l.logger, entry)
+	for _, conn := range conns {
+		_ = l.sendMessage(conn, websocket.TextMessage, msgBytes)
+	}
+}
+
+func logMessage(l *zap.Logger, entry logger.Entry) []byte {
+	return serializeMessage(l, message.CreateEvent(l, "log", entry))
+}
+
+type MessageSetLogLevelRequestData struct {
+	Level string `json:"level"`
+}
+
+type MessageSetLogLevelResponseData struct {
+	Level string `json:"level"`
+}
+
+// setLogLevel changes the level of the logger of the server until it restarts.
+func (l *WebSocketLogic) setLogLevel(conn *websocket.Conn, msg *message.Message) message.Message {
+	var data MessageSetLogLevelRequestData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Failed to unserialize request data.", nil)
+	}
+
+	if err := l.logLogic.SetLevel(data.Level, "websocket "+conn.RemoteAddr().String()); err != nil {
+		return message.CreateResponse(l.logger, msg.Action, message.StatusError, "Unknown log level.", nil)
+	}
+	return message.CreateResponse(l.logger, msg.Action, message.StatusSuccess, "Success", MessageSetLogLevelResponseData{
+		Level: l.logLogic.Level(),
+	})
+}
@@ -0,0 +1,357 @@
+// Package logquery searches the JSON log file written by the logger, together with its rotated backups.
+package logquery
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// backupTimeFormat is the time in the name of a file rotated by lumberjack, e.g. log-2024-12-01T02-00-00.000.jsonl.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// timeLayouts are the layouts of the time of a log, the first one is the one of the logger.
+var timeLayouts = []string{
+	"2006-01-02T15:04:05.000Z0700",
+	time.RFC3339Nano,
+}
+
+// Entry is a line of the log file.
+type Entry struct {
+	Time     time.Time
+	Level    zapcore.Level
+	TaskerID string
+	Message  string
+	Raw      json.RawMessage
+}
+
+// Query filters logs. Zero values match everything.
+// Level is the minimum level, Contains is a case insensitive substring of the message
+// and Limit keeps the last Limit matching logs.
+type Query struct {
+	Since    time.Time
+	Until    time.Time
+	Level    string
+	TaskerID string
+	Contains string
+	Limit    int
+}
+
+func (q *Query) match(entry *Entry) bool {
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.Time.After(q.Until) {
+		return false
+	}
+	if q.Level != "" && entry.Level < logger.ParseLevel(q.Level) {
+		return false
+	}
+	if q.TaskerID != "" && entry.TaskerID != q.TaskerID {
+		return false
+	}
+	if q.Contains != "" && !strings.Contains(strings.ToLower(entry.Message), strings.ToLower(q.Contains)) {
+		return false
+	}
+	return true
+}
+
+// Files returns the backups of the log file at path, oldest first, followed by path itself.
+// Backups rotated before since are left out, as they can not contain newer logs.
+func Files(path string, since time.Time) ([]string, error) {
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(name, ".gz")
+		if !strings.HasSuffix(stamp, ext) {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimPrefix(stamp, prefix), ext)
+		rotatedAt, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		if !since.IsZero() && rotatedAt.Before(since) {
+			continue
+		}
+		backups = append(backups, name)
+	}
+	// Names contain the rotation time, so they sort chronologically.
+	sort.Strings(backups)
+
+	files := make([]string, 0, len(backups)+1)
+	for _, name := range backups {
+		files = append(files, filepath.Join(dir, name))
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// Position is where a search stopped reading the log file, Follow continues from it.
+type Position struct {
+	file   os.FileInfo
+	offset int64
+}
+
+// Search returns the logs matched by q in the log file at path and its backups, oldest first.
+// Lines which can not be decoded are skipped.
+func Search(path string, q Query) ([]Entry, error) {
+	entries, _, err := SearchPosition(path, q)
+	return entries, err
+}
+
+// SearchPosition is Search which also returns the position after the last complete line of the log file at path.
+// A last line without newline is still being written and is left to Follow.
+func SearchPosition(path string, q Query) ([]Entry, *Position, error) {
+	files, err := Files(path, q.Since)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries := []Entry{}
+	pos := &Position{}
+	for _, file := range files {
+		p, err := scanFile(file, file == path, func(entry Entry) {
+			if !q.match(&entry) {
+				return
+			}
+			entries = append(entries, entry)
+			if q.Limit > 0 && len(entries) > 2*q.Limit {
+				entries = append(entries[:0], entries[len(entries)-q.Limit:]...)
+			}
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		if file == path && p != nil {
+			pos = p
+		}
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[len(entries)-q.Limit:]
+	}
+	return entries, pos, nil
+}
+
+// scanFile calls fn with every log of the file at path and returns the position after its last complete line.
+// If current is set, a last line without newline is skipped.
+func scanFile(path string, current bool, fn func(Entry)) (*Position, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The file was rotated away since it was listed.
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	pos := &Position{file: info}
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	reader := bufio.NewReaderSize(r, 64*1024)
+	for {
+		data, err := reader.ReadBytes('\n')
+		complete := len(data) > 0 && data[len(data)-1] == '\n'
+		if complete {
+			pos.offset += int64(len(data))
+		}
+		if complete || (!current && len(data) > 0) {
+			if entry, err := ParseLine(bytes.TrimSpace(data)); err == nil {
+				fn(entry)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return pos, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+type line struct {
+	Time     string `json:"time"`
+	Level    string `json:"level"`
+	TaskerID string `json:"tasker_id"`
+	Message  string `json:"msg"`
+}
+
+// ParseLine decodes a line of the log file. An unknown level is read as info and an unknown time is left zero.
+func ParseLine(data []byte) (Entry, error) {
+	var l line
+	if err := json.Unmarshal(data, &l); err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{
+		TaskerID: l.TaskerID,
+		Message:  l.Message,
+		Raw:      append(json.RawMessage(nil), data...),
+	}
+	if err := entry.Level.UnmarshalText([]byte(l.Level)); err != nil {
+		entry.Level = zapcore.InfoLevel
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, l.Time); err == nil {
+			entry.Time = t
+			break
+		}
+	}
+	return entry, nil
+}
+
+// followInterval is how often Follow checks the log file for new lines.
+const followInterval = 500 * time.Millisecond
+
+// Follow calls fn with every log matched by q which is appended to the log file at path, until ctx is done.
+// It starts at from, or at the end of the file if from is nil, and reopens the file when it is rotated.
+// If the file was rotated since from, it starts at the beginning of the new file.
+func Follow(ctx context.Context, path string, q Query, from *Position, fn func(Entry)) error {
+	var (
+		file   *os.File
+		offset int64
+	)
+	defer func() {
+		if file != nil {
+			file.Close()
+		}
+	}()
+	open := func(first bool) error {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		if file != nil {
+			file.Close()
+		}
+		file = f
+		offset = 0
+		if !first {
+			return nil
+		}
+		if from == nil {
+			offset, err = file.Seek(0, io.SeekEnd)
+			return err
+		}
+		if from.file == nil {
+			// The file did not exist when searched.
+			return nil
+		}
+		if info, err := file.Stat(); err == nil && os.SameFile(info, from.file) && info.Size() >= from.offset {
+			offset, err = file.Seek(from.offset, io.SeekStart)
+			return err
+		}
+		return nil
+	}
+	if err := open(true); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var pending []byte
+	buf := make([]byte, 64*1024)
+	read := func() error {
+		for {
+			n, err := file.Read(buf)
+			offset += int64(n)
+			pending = append(pending, buf[:n]...)
+			for {
+				i := bytes.IndexByte(pending, '\n')
+				if i < 0 {
+					break
+				}
+				if entry, err := ParseLine(pending[:i]); err == nil && q.match(&entry) {
+					fn(entry)
+				}
+				pending = pending[i+1:]
+			}
+			if err == io.EOF || n == 0 {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		if file != nil {
+			// Read what was written before a rotation first.
+			if err := read(); err != nil {
+				return err
+			}
+		}
+		if file == nil || rotated(file, path, offset) {
+			if err := open(false); err != nil {
+				if errors.Is(err, os.ErrNotExist) {
+					continue
+				}
+				return err
+			}
+			pending = pending[:0]
+			if err := read(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// rotated reports whether the file at path is no longer file, or was truncated below offset.
+func rotated(file *os.File, path string, offset int64) bool {
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	opened, err := file.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(current, opened) || current.Size() < offset
+}
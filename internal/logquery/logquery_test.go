@@ -0,0 +1,158 @@
+package logquery
+
+import (
+	"compress/gzip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeLines(t *testing.T, path string, lines ...string) {
+	data := strings.Join(lines, "\n") + "\n"
+	if !strings.HasSuffix(path, ".gz") {
+		require.NoError(t, os.WriteFile(path, []byte(data), 0600))
+		return
+	}
+	file, err := os.Create(path)
+	require.NoError(t, err)
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	_, err = gz.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+}
+
+func TestSearch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "log.jsonl")
+	writeLines(t, filepath.Join(dir, "log-2024-11-30T00-00-00.000.jsonl"),
+		`{"level":"error","time":"2024-11-29T10:00:00.000Z","msg":"too old"}`,
+	)
+	writeLines(t, filepath.Join(dir, "log-2024-12-01T10-00-00.000.jsonl.gz"),
+		`{"level":"info","time":"2024-12-01T09:00:00.000Z","msg":"run task","tasker_id":"a"}`,
+		`{"level":"error","time":"2024-12-01T09:30:00.000Z","msg":"failed to complete the task","tasker_id":"a"}`,
+	)
+	writeLines(t, path,
+		`not json`,
+		`{"level":"warn","time":"2024-12-01T11:00:00.000+0800","msg":"controller disconnected","tasker_id":"b"}`,
+		`{"level":"error","time":"2024-12-01T11:00:00.000Z","msg":"Failed to reconnect","tasker_id":"b"}`,
+	)
+	since := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+
+	files, err := Files(path, since)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(dir, "log-2024-12-01T10-00-00.000.jsonl.gz"), path}, files)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []string
+	}{
+		{"since", Query{Since: since}, []string{"run task", "failed to complete the task", "controller disconnected", "Failed to reconnect"}},
+		{"level", Query{Since: since, Level: "warn"}, []string{"failed to complete the task", "controller disconnected", "Failed to reconnect"}},
+		{"tasker", Query{TaskerID: "a"}, []string{"run task", "failed to complete the task"}},
+		{"contains", Query{Contains: "FAILED"}, []string{"failed to complete the task", "Failed to reconnect"}},
+		{"until", Query{Since: since, Until: time.Date(2024, 12, 1, 9, 30, 0, 0, time.UTC)}, []string{"run task", "failed to complete the task", "controller disconnected"}},
+		{"limit", Query{Limit: 2}, []string{"controller disconnected", "Failed to reconnect"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := Search(path, tt.query)
+			require.NoError(t, err)
+			var messages []string
+			for _, entry := range entries {
+				messages = append(messages, entry.Message)
+			}
+			require.Equal(t, tt.want, messages)
+		})
+	}
+}
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	writeLines(t, path, `{"level":"info","time":"2024-12-01T09:00:00.000Z","msg":"before follow"}`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, Query{Level: "warn"}, nil, func(entry Entry) {
+			received <- entry.Message
+		})
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"level":"info","time":"2024-12-01T09:00:01.000Z","msg":"filtered"}` + "\n" +
+		`{"level":"warn","time":"2024-12-01T09:00:02.000Z","msg":"appended"}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	select {
+	case msg := <-received:
+		require.Equal(t, "appended", msg)
+	case <-time.After(3 * time.Second):
+		t.Fatal("appended log not followed")
+	}
+
+	// Rotation replaces the file with a new one.
+	require.NoError(t, os.Rename(path, path+".old"))
+	writeLines(t, path, `{"level":"error","time":"2024-12-01T09:00:03.000Z","msg":"after rotation"}`)
+	select {
+	case msg := <-received:
+		require.Equal(t, "after rotation", msg)
+	case <-time.After(3 * time.Second):
+		t.Fatal("log after rotation not followed")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+}
+
+func TestFollowFromSearch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.jsonl")
+	writeLines(t, path, `{"level":"info","time":"2024-12-01T09:00:00.000Z","msg":"searched"}`)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	defer file.Close()
+	_, err = file.WriteString(`{"level":"info","time":"2024-12-01T09:00:01.000Z","msg":"being writ`)
+	require.NoError(t, err)
+
+	entries, pos, err := SearchPosition(path, Query{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "searched", entries[0].Message)
+
+	// Logs written between the search and the follow are not lost.
+	_, err = file.WriteString(`ten"}` + "\n" + `{"level":"info","time":"2024-12-01T09:00:02.000Z","msg":"before follow"}` + "\n")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := make(chan string, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- Follow(ctx, path, Query{}, pos, func(entry Entry) {
+			received <- entry.Message
+		})
+	}()
+	for _, want := range []string{"being written", "before follow"} {
+		select {
+		case msg := <-received:
+			require.Equal(t, want, msg)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("log %q not followed", want)
+		}
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+	require.Empty(t, received)
+}
@@ -0,0 +1,74 @@
+// Package metrics defines the metrics of elf-aid-magic, exported by the server at /metrics.
+package metrics
+
+import (
+	"io"
+
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/promtext"
+)
+
+var registry = promtext.NewRegistry()
+
+// Results of a custom component.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+	ResultHit     = "hit"
+	ResultMiss    = "miss"
+)
+
+var (
+	RunsStarted = registry.NewCounterVec(
+		"eam_runs_started_total",
+		"Number of runs started.",
+		"tasker_id",
+	)
+	RunsCompleted = registry.NewCounterVec(
+		"eam_runs_completed_total",
+		"Number of runs in which every task succeeded.",
+		"tasker_id",
+	)
+	RunsFailed = registry.NewCounterVec(
+		"eam_runs_failed_total",
+		"Number of runs that failed or finished with failed tasks.",
+		"tasker_id",
+	)
+	RunsInterrupted = registry.NewCounterVec(
+		"eam_runs_interrupted_total",
+		"Number of runs interrupted before the end.",
+		"tasker_id",
+	)
+	TaskDuration = registry.NewHistogramVec(
+		"eam_task_duration_seconds",
+		"Duration of the tasks of a run.",
+		promtext.DefaultBuckets,
+		"entry", "status",
+	)
+	CustomRecognitions = registry.NewCounterVec(
+		"eam_custom_recognitions_total",
+		"Number of runs of a custom recognition.",
+		"name", "result",
+	)
+	CustomActions = registry.NewCounterVec(
+		"eam_custom_actions_total",
+		"Number of runs of a custom action.",
+		"name", "result",
+	)
+	Reconnects = registry.NewCounterVec(
+		"eam_reconnects_total",
+		"Number of times a disconnected controller was reconnected or given up on after every attempt.",
+		"tasker_id", "result",
+	)
+	WebSocketClients = registry.NewGauge(
+		"eam_websocket_clients",
+		"Number of connected WebSocket clients.",
+	)
+)
+
+// ContentType is the content type of the output of Write.
+const ContentType = promtext.ContentType
+
+// Write writes every metric in the Prometheus text format.
+func Write(w io.Writer) error {
+	return registry.Write(w)
+}
@@ -0,0 +1,59 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+)
+
+// maxOutputInError is the number of bytes of the output of a failed command kept in the error.
+const maxOutputInError = 512
+
+// ExecNotifier runs a command for every event. The Payload is written to its stdin, and the event,
+// the tasker id and the summary are set in the environment variables EAM_EVENT, EAM_TASKER_ID and EAM_SUMMARY.
+type ExecNotifier struct {
+	conf config.ExecNotifierConfig
+}
+
+func NewExecNotifier(conf config.ExecNotifierConfig) (*ExecNotifier, error) {
+	if conf.Command == "" {
+		return nil, fmt.Errorf("%w: exec command is required", ErrInvalidConfig)
+	}
+	return &ExecNotifier{
+		conf: conf,
+	}, nil
+}
+
+func (n *ExecNotifier) Notify(ctx context.Context, event Event) error {
+	payload := NewPayload(event)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, n.conf.Command, n.conf.Args...)
+	cmd.Env = append(os.Environ(),
+		"EAM_EVENT="+event.Name,
+		"EAM_TASKER_ID="+event.TaskerID,
+		"EAM_SUMMARY="+payload.Summary,
+	)
+	cmd.Stdin = bytes.NewReader(data)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		out := strings.TrimSpace(string(output))
+		if len(out) > maxOutputInError {
+			out = out[:maxOutputInError] + "..."
+		}
+		if out == "" {
+			return err
+		}
+		return fmt.Errorf("%w: %s", err, out)
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+// Package notify sends the outcomes of runs to webhooks, mailboxes and commands.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+	"go.uber.org/zap"
+)
+
+// Events which can be notified, they have the names of the events of an operator.
+const (
+	EventRunCompleted = "run_completed"
+	EventTaskFailed   = "task_failed"
+	EventDisconnected = "disconnected"
+)
+
+var Events = []string{EventRunCompleted, EventTaskFailed, EventDisconnected}
+
+// Types of the notifiers in the config.
+const (
+	TypeWebhook = "webhook"
+	TypeSMTP    = "smtp"
+	TypeExec    = "exec"
+)
+
+const defaultTimeout = 10 * time.Second
+
+var ErrInvalidConfig = errors.New("invalid notifier config")
+
+// Event is an event of an operator. Data is a history.Run for run_completed,
+// a history.TaskResult for task_failed and nil for disconnected.
+type Event struct {
+	Name     string      `json:"event"`
+	TaskerID string      `json:"tasker_id"`
+	Data     interface{} `json:"data"`
+	Time     time.Time   `json:"time"`
+}
+
+// Payload is the JSON sent by the webhook and exec notifiers.
+type Payload struct {
+	Event
+	Summary string `json:"summary"`
+}
+
+func NewPayload(event Event) Payload {
+	return Payload{
+		Event:   event,
+		Summary: Summary(event),
+	}
+}
+
+// Summary returns a one line description of event.
+func Summary(event Event) string {
+	switch data := event.Data.(type) {
+	case history.Run:
+		return runSummary(event.TaskerID, &data)
+	case *history.Run:
+		return runSummary(event.TaskerID, data)
+	case history.TaskResult:
+		return taskSummary(event.TaskerID, &data)
+	case *history.TaskResult:
+		return taskSummary(event.TaskerID, data)
+	}
+	switch event.Name {
+	case EventDisconnected:
+		return fmt.Sprintf("The controller of tasker %s disconnected.", event.TaskerID)
+	default:
+		return fmt.Sprintf("Tasker %s: %s.", event.TaskerID, event.Name)
+	}
+}
+
+func runSummary(taskerID string, run *history.Run) string {
+	failed := 0
+	for _, task := range run.Tasks {
+		if task.Status == history.StatusFailed {
+			failed++
+		}
+	}
+	summary := fmt.Sprintf("Run %s of tasker %s %s after %s, %d tasks, %d failed",
+		run.ID,
+		taskerID,
+		run.Status,
+		run.EndedAt.Sub(run.StartedAt).Round(time.Second),
+		len(run.Tasks),
+		failed,
+	)
+	if run.Error != "" {
+		summary += ": " + run.Error
+	}
+	return summary + "."
+}
+
+func taskSummary(taskerID string, task *history.TaskResult) string {
+	summary := fmt.Sprintf("Task %s of tasker %s %s", task.Entry, taskerID, task.Status)
+	if task.Error != "" {
+		summary += ": " + task.Error
+	}
+	if task.DebugBundle != "" {
+		summary += fmt.Sprintf(" (debug bundle %s)", task.DebugBundle)
+	}
+	return summary + "."
+}
+
+// Notifier sends an event somewhere. Notify should return when ctx is done.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Filter selects the events sent to a notifier. Empty lists match everything,
+// RunStatuses only applies to run_completed.
+type Filter struct {
+	Events      []string
+	RunStatuses []string
+	Taskers     []string
+}
+
+func (f *Filter) Match(event Event) bool {
+	if len(f.Events) > 0 && !slices.Contains(f.Events, event.Name) {
+		return false
+	}
+	if len(f.Taskers) > 0 && !slices.Contains(f.Taskers, event.TaskerID) {
+		return false
+	}
+	if len(f.RunStatuses) > 0 && event.Name == EventRunCompleted {
+		status := ""
+		switch run := event.Data.(type) {
+		case history.Run:
+			status = run.Status
+		case *history.Run:
+			status = run.Status
+		}
+		if !slices.Contains(f.RunStatuses, status) {
+			return false
+		}
+	}
+	return true
+}
+
+type registered struct {
+	name     string
+	filter   Filter
+	timeout  time.Duration
+	notifier Notifier
+}
+
+// Dispatcher sends every event to the notifiers whose filter matches it, in the background.
+type Dispatcher struct {
+	logger    *zap.Logger
+	notifiers []*registered
+	wg        sync.WaitGroup
+	mutex     sync.Mutex
+}
+
+func NewDispatcher(logger *zap.Logger) *Dispatcher {
+	return &Dispatcher{
+		logger: logger,
+	}
+}
+
+// NewDispatcherFromConfig returns a dispatcher with a notifier for every config.
+func NewDispatcherFromConfig(confs []*config.NotifierConfig, logger *zap.Logger) (*Dispatcher, error) {
+	d := NewDispatcher(logger)
+	for i, conf := range confs {
+		name := conf.Name
+		if name == "" {
+			name = fmt.Sprintf("%s-%d", conf.Type, i+1)
+		}
+		if err := validateFilter(conf); err != nil {
+			return nil, fmt.Errorf("notifier %s: %w", name, err)
+		}
+		notifier, err := NewNotifier(conf)
+		if err != nil {
+			return nil, fmt.Errorf("notifier %s: %w", name, err)
+		}
+		filter := Filter{
+			Events:      conf.Events,
+			RunStatuses: conf.RunStatuses,
+			Taskers:     conf.Taskers,
+		}
+		d.Add(name, filter, time.Duration(conf.Timeout)*time.Second, notifier)
+	}
+	return d, nil
+}
+
+// NewNotifier returns the notifier of the type of conf.
+func NewNotifier(conf *config.NotifierConfig) (Notifier, error) {
+	switch conf.Type {
+	case TypeWebhook:
+		return NewWebhookNotifier(conf.Webhook)
+	case TypeSMTP:
+		return NewSMTPNotifier(conf.SMTP)
+	case TypeExec:
+		return NewExecNotifier(conf.Exec)
+	default:
+		return nil, fmt.Errorf("%w: unknown type %q", ErrInvalidConfig, conf.Type)
+	}
+}
+
+func validateFilter(conf *config.NotifierConfig) error {
+	for _, event := range conf.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidConfig, event)
+		}
+	}
+	for _, status := range conf.RunStatuses {
+		switch status {
+		case history.StatusSuccess, history.StatusFailed, history.StatusInterrupted:
+		default:
+			return fmt.Errorf("%w: unknown run status %q", ErrInvalidConfig, status)
+		}
+	}
+	return nil
+}
+
+// Add registers notifier as name. A timeout of 0 or less uses the default timeout of 10 seconds.
+func (d *Dispatcher) Add(name string, filter Filter, timeout time.Duration, notifier Notifier) {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.notifiers = append(d.notifiers, &registered{
+		name:     name,
+		filter:   filter,
+		timeout:  timeout,
+		notifier: notifier,
+	})
+}
+
+func (d *Dispatcher) Len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.notifiers)
+}
+
+// Dispatch sends event to the matching notifiers without waiting for them. Events which can not be notified are ignored.
+func (d *Dispatcher) Dispatch(event Event) {
+	if !slices.Contains(Events, event.Name) {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	d.mutex.Lock()
+	notifiers := make([]*registered, len(d.notifiers))
+	copy(notifiers, d.notifiers)
+	d.mutex.Unlock()
+
+	for _, n := range notifiers {
+		if !n.filter.Match(event) {
+			continue
+		}
+		d.wg.Add(1)
+		go func(n *registered) {
+			defer d.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+			defer cancel()
+			if err := n.notifier.Notify(ctx, event); err != nil {
+				d.logger.Warn("failed to notify",
+					zap.String("notifier", n.name),
+					zap.String("event", event.Name),
+					zap.String("tasker id", event.TaskerID),
+					zap.Error(err),
+				)
+				return
+			}
+			d.logger.Debug("notify",
+				zap.String("notifier", n.name),
+				zap.String("event", event.Name),
+				zap.String("tasker id", event.TaskerID),
+			)
+		}(n)
+	}
+}
+
+// Wait waits for the notifications in progress until ctx is done.
+func (d *Dispatcher) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
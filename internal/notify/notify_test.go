@@ -0,0 +1,196 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var (
+	startedAt = time.Date(2024, 12, 1, 2, 0, 0, 0, time.UTC)
+
+	failedRun = Event{
+		Name:     EventRunCompleted,
+		TaskerID: "a",
+		Data: history.Run{
+			ID:        "r1",
+			Status:    history.StatusFailed,
+			Error:     "some tasks failed",
+			StartedAt: startedAt,
+			EndedAt:   startedAt.Add(90 * time.Second),
+			Tasks: []history.TaskResult{
+				{Entry: "Startup", Status: history.StatusSuccess},
+				{Entry: "MapNavigation", Status: history.StatusFailed},
+			},
+		},
+		Time: startedAt.Add(90 * time.Second),
+	}
+	successfulRun = Event{
+		Name:     EventRunCompleted,
+		TaskerID: "a",
+		Data:     history.Run{ID: "r2", Status: history.StatusSuccess},
+	}
+	failedTask = Event{
+		Name:     EventTaskFailed,
+		TaskerID: "b",
+		Data:     history.TaskResult{Entry: "MapNavigation", Status: history.StatusFailed, Error: "failed to complete the task"},
+	}
+	disconnected = Event{Name: EventDisconnected, TaskerID: "a"}
+)
+
+func TestSummary(t *testing.T) {
+	require.Equal(t, "Run r1 of tasker a failed after 1m30s, 2 tasks, 1 failed: some tasks failed.", Summary(failedRun))
+	require.Equal(t, "Task MapNavigation of tasker b failed: failed to complete the task.", Summary(failedTask))
+	require.Equal(t, "The controller of tasker a disconnected.", Summary(disconnected))
+}
+
+func TestWebhookDispatch(t *testing.T) {
+	var (
+		mutex     sync.Mutex
+		payloads  []Payload
+		headers   []string
+		decodeErr error
+	)
+	// The handler runs outside the test goroutine, so errors are recorded and checked after Wait.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload Payload
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		mutex.Lock()
+		if err != nil && decodeErr == nil {
+			decodeErr = err
+		}
+		payloads = append(payloads, payload)
+		headers = append(headers, r.Header.Get("Authorization"))
+		mutex.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcherFromConfig([]*config.NotifierConfig{
+		{
+			Type:        TypeWebhook,
+			Events:      []string{EventRunCompleted, EventDisconnected},
+			RunStatuses: []string{history.StatusFailed},
+			Taskers:     []string{"a"},
+			Webhook: config.WebhookNotifierConfig{
+				URL:     server.URL,
+				Headers: map[string]string{"authorization": "Bearer token"},
+			},
+		},
+	}, zap.NewNop())
+	require.NoError(t, err)
+
+	for _, event := range []Event{failedRun, successfulRun, failedTask, disconnected, {Name: "run_started", TaskerID: "a"}} {
+		d.Dispatch(event)
+	}
+	require.NoError(t, d.Wait(context.Background()))
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.NoError(t, decodeErr)
+	require.Len(t, payloads, 2)
+	var events []string
+	for _, payload := range payloads {
+		events = append(events, payload.Name)
+	}
+	require.ElementsMatch(t, []string{EventRunCompleted, EventDisconnected}, events)
+	require.Equal(t, []string{"Bearer token", "Bearer token"}, headers)
+}
+
+func TestWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	n, err := NewWebhookNotifier(config.WebhookNotifierConfig{URL: server.URL})
+	require.NoError(t, err)
+	require.ErrorContains(t, n.Notify(context.Background(), failedRun), "500")
+}
+
+func TestSMTPNotify(t *testing.T) {
+	n, err := NewSMTPNotifier(config.SMTPNotifierConfig{
+		Host:     "smtp.example.com",
+		Username: "eam",
+		Password: "secret",
+		From:     "eam@example.com",
+		To:       []string{"ops@example.com", "me@example.com"},
+	})
+	require.NoError(t, err)
+
+	var (
+		addr string
+		to   []string
+		msg  string
+	)
+	n.sendMail = func(a string, _ smtp.Auth, _ string, t []string, m []byte) error {
+		addr, to, msg = a, t, string(m)
+		return nil
+	}
+	require.NoError(t, n.Notify(context.Background(), failedRun))
+	require.Equal(t, "smtp.example.com:587", addr)
+	require.Equal(t, []string{"ops@example.com", "me@example.com"}, to)
+	require.Contains(t, msg, "To: ops@example.com, me@example.com\r\n")
+	require.Contains(t, msg, "Subject: [elf-aid-magic] Run r1 of tasker a failed")
+	require.Contains(t, msg, "\r\n\r\nRun r1 of tasker a failed")
+}
+
+func TestExecNotify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires sh")
+	}
+	out := filepath.Join(t.TempDir(), "out")
+	n, err := NewExecNotifier(config.ExecNotifierConfig{
+		Command: "sh",
+		Args:    []string{"-c", `printf '%s\n' "$EAM_EVENT" "$EAM_TASKER_ID" > "$0" && cat >> "$0"`, out},
+	})
+	require.NoError(t, err)
+	require.NoError(t, n.Notify(context.Background(), failedTask))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.SplitN(string(data), "\n", 3)
+	require.Equal(t, EventTaskFailed, lines[0])
+	require.Equal(t, "b", lines[1])
+	var payload Payload
+	require.NoError(t, json.Unmarshal([]byte(lines[2]), &payload))
+	require.Equal(t, Summary(failedTask), payload.Summary)
+
+	n, err = NewExecNotifier(config.ExecNotifierConfig{Command: "sh", Args: []string{"-c", "echo boom; exit 3"}})
+	require.NoError(t, err)
+	require.ErrorContains(t, n.Notify(context.Background(), failedTask), "boom")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		conf config.NotifierConfig
+	}{
+		{"unknown type", config.NotifierConfig{Type: "slack"}},
+		{"unknown event", config.NotifierConfig{Type: TypeExec, Events: []string{"run_started"}, Exec: config.ExecNotifierConfig{Command: "true"}}},
+		{"unknown run status", config.NotifierConfig{Type: TypeExec, RunStatuses: []string{"running"}, Exec: config.ExecNotifierConfig{Command: "true"}}},
+		{"webhook without url", config.NotifierConfig{Type: TypeWebhook}},
+		{"smtp without to", config.NotifierConfig{Type: TypeSMTP, SMTP: config.SMTPNotifierConfig{Host: "h", From: "f"}}},
+		{"exec without command", config.NotifierConfig{Type: TypeExec}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDispatcherFromConfig([]*config.NotifierConfig{&tt.conf}, zap.NewNop())
+			require.ErrorIs(t, err, ErrInvalidConfig)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+)
+
+// subjectPrefix starts the subject of every mail.
+const subjectPrefix = "[elf-aid-magic] "
+
+// SMTPNotifier mails the summary and the payload of every event.
+type SMTPNotifier struct {
+	conf config.SMTPNotifierConfig
+	// sendMail is smtp.SendMail, replaced in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func NewSMTPNotifier(conf config.SMTPNotifierConfig) (*SMTPNotifier, error) {
+	if conf.Host == "" || conf.From == "" || len(conf.To) == 0 {
+		return nil, fmt.Errorf("%w: smtp host, from and to are required", ErrInvalidConfig)
+	}
+	if conf.Port == 0 {
+		conf.Port = 587
+	}
+	return &SMTPNotifier{
+		conf:     conf,
+		sendMail: smtp.SendMail,
+	}, nil
+}
+
+func (n *SMTPNotifier) Notify(ctx context.Context, event Event) error {
+	msg, err := n.message(event)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if n.conf.Username != "" {
+		auth = smtp.PlainAuth("", n.conf.Username, n.conf.Password, n.conf.Host)
+	}
+	addr := net.JoinHostPort(n.conf.Host, strconv.Itoa(n.conf.Port))
+
+	// smtp.SendMail can not be cancelled, stop waiting for it when ctx is done.
+	done := make(chan error, 1)
+	go func() {
+		done <- n.sendMail(addr, auth, n.conf.From, n.conf.To, msg)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (n *SMTPNotifier) message(event Event) ([]byte, error) {
+	payload := NewPayload(event)
+	data, err := json.MarshalIndent(payload, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", n.conf.From)
+	header("To", strings.Join(n.conf.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subjectPrefix+payload.Summary))
+	header("Date", event.Time.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	buf.WriteString("\r\n")
+	body := payload.Summary + "\n\n" + string(data) + "\n"
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
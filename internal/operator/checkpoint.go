@@ -0,0 +1,74 @@
+package operator
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Checkpoint records the progress of a run, so that a killed run can be resumed.
+type Checkpoint struct {
+	RunID    string `json:"run_id"`
+	TaskerID string `json:"tasker_id"`
+	// Index is the index in the tasks of the tasker of the last completed task.
+	Index     int       `json:"index"`
+	Entry     string    `json:"entry"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func checkpointPath(taskerID string) (string, error) {
+	exePath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	exeDir := filepath.Dir(exePath)
+	return filepath.Join(exeDir, "data", "checkpoint", taskerID+".json"), nil
+}
+
+func loadCheckpoint(taskerID string) (*Checkpoint, error) {
+	path, err := checkpointPath(taskerID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+func saveCheckpoint(cp *Checkpoint) error {
+	path, err := checkpointPath(cp.TaskerID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so that a kill never leaves a truncated checkpoint.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func removeCheckpoint(taskerID string) error {
+	path, err := checkpointPath(taskerID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,266 @@
+package operator
+
+import (
+	"github.com/MaaXYZ/maa-framework-go"
+	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/pkg/winmatch"
+	"go.uber.org/zap"
+)
+
+type controller struct {
+	ctrlType string
+	ctrl     maa.Controller
+}
+
+type ControllerSwitchedData struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Reason string `json:"reason"`
+}
+
+// InitController creates every controller of the tasker in priority order and binds the first one.
+func (o *Operator) InitController() bool {
+	if o.Running() {
+		o.logger.Error("failed to init controller", zap.Error(ErrAlreadyRunning))
+		return false
+	}
+	tasker, ok := o.getTaskerConfig()
+	if !ok {
+		return false
+	}
+
+	o.destroyControllers()
+	for _, ctrlType := range tasker.GetCtrlTypes() {
+		var ctrl maa.Controller
+		switch ctrlType {
+		case "adb":
+			ctrl = o.newAdbController(tasker)
+		case "win32":
+			ctrl = o.newWin32Controller(tasker)
+		default:
+			o.logger.Error(
+				"unknown ctrl type",
+				zap.String("ctrl type", ctrlType),
+			)
+			continue
+		}
+		if ctrl == nil {
+			continue
+		}
+		o.ctrls = append(o.ctrls, controller{
+			ctrlType: ctrlType,
+			ctrl:     ctrl,
+		})
+	}
+
+	if len(o.ctrls) == 0 {
+		o.logger.Error("no controller available")
+		o.recordError("no controller available")
+		return false
+	}
+	return o.bindController(0)
+}
+
+func (o *Operator) newAdbController(tasker *config.TaskerConfig) maa.Controller {
+	option, err := config.NewAdbControllerOption(o.conf, tasker)
+	if err != nil {
+		o.logger.Error("invalid adb controller config",
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	o.logger.Info(
+		"adb config",
+		zap.String("config", option.Config),
+	)
+
+	ctrl := maa.NewAdbController(
+		option.AdbPath,
+		option.SerialNumber,
+		maa.AdbScreencapMethod(option.Screencap),
+		maa.AdbInputMethod(option.Input),
+		option.Config,
+		"./MaaAgentBinary",
+		nil,
+	)
+	if ctrl == nil {
+		o.logger.Error("failed to init adb controller")
+		return nil
+	}
+	o.logger.Info(
+		"create adb controller",
+		zap.String("path", option.AdbPath),
+		zap.String("address", option.SerialNumber),
+	)
+	return ctrl
+}
+
+func (o *Operator) newWin32Controller(tasker *config.TaskerConfig) maa.Controller {
+	option, err := config.NewWin32ControllerOption(tasker)
+	if err != nil {
+		o.logger.Error("invalid win32 controller config",
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	windows := o.toolkit.FindDesktopWindows()
+	candidates := make([]winmatch.Window, len(windows))
+	for i, w := range windows {
+		candidates[i] = winmatch.Window{
+			WindowName: w.WindowName,
+			ClassName:  w.ClassName,
+		}
+	}
+	index, err := winmatch.Find(candidates, option.Criteria)
+	if err != nil {
+		o.logger.Error("not found target window",
+			zap.String("window name", option.Criteria.WindowName.Value),
+			zap.String("class name", option.Criteria.ClassName.Value),
+			zap.Int("index", option.Criteria.Index),
+			zap.Error(err),
+		)
+		return nil
+	}
+	handle := windows[index].Handle
+	o.logger.Info("found target window",
+		zap.String("window name", windows[index].WindowName),
+		zap.String("class name", windows[index].ClassName),
+	)
+
+	ctrl := maa.NewWin32Controller(
+		handle,
+		maa.Win32ScreencapMethod(option.Screencap),
+		maa.Win32InputMethod(option.Input),
+		nil,
+	)
+	if ctrl == nil {
+		o.logger.Error("failed to init win32 controller")
+		return nil
+	}
+	o.logger.Info("create win32 controller")
+	ctrl.SetScreenshotUseRawSize(true)
+	return ctrl
+}
+
+func (o *Operator) bindController(index int) bool {
+	c := o.ctrls[index]
+	if ok := o.tasker.BindController(c.ctrl); !ok {
+		o.logger.Error("failed to bind controller",
+			zap.String("ctrl type", c.ctrlType),
+		)
+		return false
+	}
+	o.mutex.Lock()
+	o.ctrl = c.ctrl
+	o.active = index
+	o.mutex.Unlock()
+	return true
+}
+
+func (o *Operator) connectController(index int) bool {
+	if !o.bindController(index) {
+		return false
+	}
+	if !o.ctrls[index].ctrl.PostConnect().Wait().Success() {
+		o.logger.Error("failed to connect",
+			zap.String("ctrl type", o.ctrls[index].ctrlType),
+		)
+		return false
+	}
+	return true
+}
+
+// Connect connects the controllers in priority order, starting with the active one, and keeps the first one that succeeds.
+// After a failover the active controller is the last one that worked, so it is tried first when reconnecting.
+func (o *Operator) Connect() bool {
+	o.mutex.Lock()
+	from := o.active
+	o.mutex.Unlock()
+
+	connected := false
+	for i := 0; i < len(o.ctrls); i++ {
+		index := (from + i) % len(o.ctrls)
+		if o.connectController(index) {
+			if index != from {
+				o.controllerSwitched(from, index, "connect failed")
+			}
+			connected = true
+			break
+		}
+	}
+	if !connected {
+		o.logger.Error("failed to connect any controller")
+		o.recordError("failed to connect any controller")
+		return false
+	}
+	if !o.tasker.Initialized() {
+		o.logger.Error("failed to initialize tasker instance")
+		o.recordError("failed to initialize tasker instance")
+		return false
+	}
+	o.clearError()
+	return true
+}
+
+// controllerHealthy reports whether the active controller is connected and can take a screencap.
+func (o *Operator) controllerHealthy() bool {
+	o.mutex.Lock()
+	ctrl := o.ctrl
+	o.mutex.Unlock()
+	if ctrl == nil || !ctrl.Connected() {
+		return false
+	}
+	return ctrl.PostScreencap().Wait().Success()
+}
+
+// failover connects the other controllers in priority order, starting after the active one.
+// If none of them connects, the active controller stays bound.
+func (o *Operator) failover(reason string) bool {
+	o.mutex.Lock()
+	from := o.active
+	o.mutex.Unlock()
+	for i := 1; i < len(o.ctrls); i++ {
+		next := (from + i) % len(o.ctrls)
+		if o.connectController(next) {
+			o.controllerSwitched(from, next, reason)
+			o.clearError()
+			return true
+		}
+	}
+	o.bindController(from)
+	o.logger.Error("no controller to fail over to",
+		zap.String("reason", reason),
+	)
+	return false
+}
+
+func (o *Operator) controllerSwitched(from, to int, reason string) {
+	data := ControllerSwitchedData{
+		From:   o.ctrls[from].ctrlType,
+		To:     o.ctrls[to].ctrlType,
+		Reason: reason,
+	}
+	o.logger.Warn("switch controller",
+		zap.String("from", data.From),
+		zap.String("to", data.To),
+		zap.String("reason", data.Reason),
+	)
+	o.emit(EventControllerSwitched, data)
+}
+
+func (o *Operator) destroyControllers() {
+	// Unset ctrl first, so that Screen never reads a destroyed controller.
+	o.mutex.Lock()
+	ctrls := o.ctrls
+	o.ctrls = nil
+	o.ctrl = nil
+	o.active = 0
+	o.lastBox = nil
+	o.mutex.Unlock()
+
+	for _, c := range ctrls {
+		c.ctrl.Destroy()
+	}
+}
@@ -0,0 +1,98 @@
+package operator
+
+import (
+	"encoding/json"
+	"image"
+
+	"github.com/MaaXYZ/maa-framework-go"
+	"github.com/dongwlin/elf-aid-magic/internal/debugbundle"
+	"go.uber.org/zap"
+)
+
+// maxBundleRecognitions is the number of the most recent recognitions saved in a debug bundle.
+const maxBundleRecognitions = 10
+
+type DebugBundleSavedData struct {
+	Name  string `json:"name"`
+	RunID string `json:"run_id"`
+	Entry string `json:"entry"`
+}
+
+func (o *Operator) initDebugBundles() {
+	retention := 0
+	if o.conf.Debug != nil {
+		retention = o.conf.Debug.BundleRetention
+	}
+	o.bundles = debugbundle.NewDefaultStore(retention)
+}
+
+// saveDebugBundle saves the last screencap of the controller and the recent recognitions of job.
+// It returns the name of the bundle, or an empty string if it could not be saved.
+func (o *Operator) saveDebugBundle(runID, entry, param, errMsg string, job *maa.TaskJob) string {
+	detail := debugbundle.Detail{
+		TaskerID:     o.ID,
+		RunID:        runID,
+		Entry:        entry,
+		Param:        param,
+		Error:        errMsg,
+		Recognitions: recentRecognitions(job),
+	}
+	name, err := o.bundles.Save(detail, o.cacheImage())
+	if err != nil {
+		o.logger.Error("failed to save debug bundle",
+			zap.String("entry", entry),
+			zap.Error(err),
+		)
+		return name
+	}
+	o.logger.Info("save debug bundle",
+		zap.String("entry", entry),
+		zap.String("name", name),
+	)
+	o.emit(EventDebugBundleSaved, DebugBundleSavedData{
+		Name:  name,
+		RunID: runID,
+		Entry: entry,
+	})
+	return name
+}
+
+func (o *Operator) cacheImage() image.Image {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.ctrl == nil {
+		return nil
+	}
+	return o.ctrl.CacheImage()
+}
+
+func recentRecognitions(job *maa.TaskJob) []debugbundle.Recognition {
+	recognitions := []debugbundle.Recognition{}
+	if job == nil {
+		return recognitions
+	}
+	detail := job.GetDetail()
+	if detail == nil {
+		return recognitions
+	}
+	for _, node := range detail.NodeDetails {
+		if node == nil || node.Recognition == nil {
+			continue
+		}
+		reco := node.Recognition
+		recognition := debugbundle.Recognition{
+			Name:      reco.Name,
+			Algorithm: reco.Algorithm,
+			Hit:       reco.Hit,
+			Box:       reco.Box.ToInts(),
+		}
+		if json.Valid([]byte(reco.DetailJson)) {
+			recognition.Detail = json.RawMessage(reco.DetailJson)
+		}
+		recognitions = append(recognitions, recognition)
+	}
+	if len(recognitions) > maxBundleRecognitions {
+		recognitions = recognitions[len(recognitions)-maxBundleRecognitions:]
+	}
+	return recognitions
+}
@@ -0,0 +1,50 @@
+package operator
+
+import "time"
+
+// Event names emitted by an Operator.
+const (
+	EventControllerSwitched = "controller_switched"
+	EventDisconnected       = "disconnected"
+	EventReconnecting       = "reconnecting"
+	EventReconnected        = "reconnected"
+	EventReconnectFailed    = "reconnect_failed"
+	EventRunStarted         = "run_started"
+	EventTaskCompleted      = "task_completed"
+	EventTaskFailed         = "task_failed"
+	EventRunCompleted       = "run_completed"
+	EventDebugBundleSaved   = "debug_bundle_saved"
+)
+
+type Event struct {
+	Name     string      `json:"name"`
+	TaskerID string      `json:"tasker_id"`
+	Data     interface{} `json:"data"`
+	Time     time.Time   `json:"time"`
+}
+
+type EventListener func(event Event)
+
+// AddEventListener registers listener to be called synchronously for every event of the operator.
+func (o *Operator) AddEventListener(listener EventListener) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.listeners = append(o.listeners, listener)
+}
+
+func (o *Operator) emit(name string, data interface{}) {
+	o.mutex.Lock()
+	listeners := make([]EventListener, len(o.listeners))
+	copy(listeners, o.listeners)
+	o.mutex.Unlock()
+
+	event := Event{
+		Name:     name,
+		TaskerID: o.ID,
+		Data:     data,
+		Time:     time.Now(),
+	}
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
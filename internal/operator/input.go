@@ -0,0 +1,133 @@
+package operator
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/MaaXYZ/maa-framework-go"
+	"go.uber.org/zap"
+)
+
+// Input types
+const (
+	InputClick = "click"
+	InputSwipe = "swipe"
+	InputKey   = "key"
+	InputText  = "text"
+)
+
+// MaxSwipeDuration is the longest swipe in milliseconds, so that a single input cannot hold the controller for long.
+const MaxSwipeDuration = 5000
+
+var (
+	ErrInputDisabled     = errors.New("manual input is disabled")
+	ErrRunOwnsController = errors.New("a run owns the controller")
+	ErrNotConnected      = errors.New("controller is not connected")
+	ErrInvalidInput      = errors.New("invalid input")
+	ErrInputFailed       = errors.New("failed to post input")
+)
+
+// Input is a manual input posted to the controller of an operator.
+// Keycode is an Android keycode for adb controllers and a virtual-key code for win32 controllers.
+type Input struct {
+	Type     string `json:"type"`
+	X        int32  `json:"x"`
+	Y        int32  `json:"y"`
+	X2       int32  `json:"x2"`
+	Y2       int32  `json:"y2"`
+	Duration int    `json:"duration"` // milliseconds of a swipe
+	Keycode  int32  `json:"keycode"`
+	Text     string `json:"text"`
+}
+
+func (i Input) Validate() error {
+	switch i.Type {
+	case InputClick:
+		if i.X < 0 || i.Y < 0 {
+			return fmt.Errorf("%w: negative coordinate", ErrInvalidInput)
+		}
+	case InputSwipe:
+		if i.X < 0 || i.Y < 0 || i.X2 < 0 || i.Y2 < 0 {
+			return fmt.Errorf("%w: negative coordinate", ErrInvalidInput)
+		}
+		if i.Duration < 0 {
+			return fmt.Errorf("%w: negative duration", ErrInvalidInput)
+		}
+		if i.Duration > MaxSwipeDuration {
+			return fmt.Errorf("%w: duration exceeds %d milliseconds", ErrInvalidInput, MaxSwipeDuration)
+		}
+	case InputKey:
+		if i.Keycode <= 0 {
+			return fmt.Errorf("%w: keycode must be positive", ErrInvalidInput)
+		}
+	case InputText:
+		if i.Text == "" {
+			return fmt.Errorf("%w: text is empty", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidInput, i.Type)
+	}
+	return nil
+}
+
+// PostInput posts input to the active controller and waits for it to be done.
+// It is rejected unless server.manual_input is set, as the server has no authentication.
+// While a run owns the controller, the input is rejected unless force is set.
+// source describes where the input comes from, e.g. the remote address, and is only logged.
+func (o *Operator) PostInput(input Input, force bool, source string) error {
+	if !o.conf.Server.ManualInput {
+		o.logger.Warn("reject manual input",
+			zap.String("source", source),
+			zap.Error(ErrInputDisabled),
+		)
+		return ErrInputDisabled
+	}
+	if err := input.Validate(); err != nil {
+		return err
+	}
+
+	// The mutex is only held to read the state, so that events, status and the screen stream do not wait for the input.
+	// destroyMutex keeps the controller alive until the input is done,
+	// inputMutex keeps a run from starting between the check of running and the input.
+	o.destroyMutex.Lock()
+	defer o.destroyMutex.Unlock()
+	o.inputMutex.Lock()
+	defer o.inputMutex.Unlock()
+
+	o.mutex.Lock()
+	running, ctrl := o.running, o.ctrl
+	o.mutex.Unlock()
+
+	fields := []zap.Field{
+		zap.String("source", source),
+		zap.Any("input", input),
+		zap.Bool("force", force),
+	}
+	if running && !force {
+		o.logger.Warn("reject manual input", append(fields, zap.Error(ErrRunOwnsController))...)
+		return ErrRunOwnsController
+	}
+	if ctrl == nil || !ctrl.Connected() {
+		o.logger.Warn("reject manual input", append(fields, zap.Error(ErrNotConnected))...)
+		return ErrNotConnected
+	}
+	o.logger.Info("manual input", fields...)
+
+	var job *maa.Job
+	switch input.Type {
+	case InputClick:
+		job = ctrl.PostClick(input.X, input.Y)
+	case InputSwipe:
+		job = ctrl.PostSwipe(input.X, input.Y, input.X2, input.Y2, time.Duration(input.Duration)*time.Millisecond)
+	case InputKey:
+		job = ctrl.PostPressKey(input.Keycode)
+	case InputText:
+		job = ctrl.PostInputText(input.Text)
+	}
+	if !job.Wait().Success() {
+		o.logger.Error("failed to post manual input", fields...)
+		return ErrInputFailed
+	}
+	return nil
+}
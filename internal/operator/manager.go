@@ -1,9 +1,15 @@
 package operator
 
-import "sync"
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
 
 type Manager struct {
 	operators map[string]*Operator
+	listeners []EventListener
 	mutex     sync.Mutex
 }
 
@@ -22,9 +28,28 @@ func (m *Manager) AddOperator(operator *Operator) bool {
 	}
 
 	m.operators[operator.ID] = operator
+	operator.AddEventListener(m.dispatch)
 	return true
 }
 
+// AddEventListener registers listener for the events of every operator of the manager.
+func (m *Manager) AddEventListener(listener EventListener) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.listeners = append(m.listeners, listener)
+}
+
+func (m *Manager) dispatch(event Event) {
+	m.mutex.Lock()
+	listeners := make([]EventListener, len(m.listeners))
+	copy(listeners, m.listeners)
+	m.mutex.Unlock()
+
+	for _, listener := range listeners {
+		listener(event)
+	}
+}
+
 func (m *Manager) RemoveOperatorByID(id string) bool {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
@@ -44,3 +69,48 @@ func (m *Manager) GetOperatorByID(id string) (*Operator, bool) {
 	operator, exists := m.operators[id]
 	return operator, exists
 }
+
+// Operators returns every operator of the manager, sorted by id.
+func (m *Manager) Operators() []*Operator {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	operators := make([]*Operator, 0, len(m.operators))
+	for _, operator := range m.operators {
+		operators = append(operators, operator)
+	}
+	sort.Slice(operators, func(i, j int) bool {
+		return operators[i].ID < operators[j].ID
+	})
+	return operators
+}
+
+// Shutdown stops the runs of every operator, waits for them to return until ctx is done, then destroys the operators.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	operators := m.Operators()
+	for _, operator := range operators {
+		operator.StopRun()
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	var err error
+wait:
+	for _, operator := range operators {
+		for operator.Running() {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+				break wait
+			case <-ticker.C:
+			}
+		}
+	}
+
+	for _, operator := range operators {
+		if !operator.Running() {
+			operator.Destroy()
+		}
+	}
+	return err
+}
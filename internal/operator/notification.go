@@ -0,0 +1,37 @@
+package operator
+
+import (
+	"github.com/MaaXYZ/maa-framework-go"
+)
+
+// notification receives the notifications of the tasker of an operator.
+type notification struct {
+	o *Operator
+}
+
+func (n *notification) OnResourceLoading(notifyType maa.NotificationType, detail maa.ResourceLoadingDetail) {
+}
+
+func (n *notification) OnControllerAction(notifyType maa.NotificationType, detail maa.ControllerActionDetail) {
+}
+
+func (n *notification) OnTaskerTask(notifyType maa.NotificationType, detail maa.TaskerTaskDetail) {
+}
+
+func (n *notification) OnTaskNextList(notifyType maa.NotificationType, detail maa.TaskNextListDetail) {
+}
+
+func (n *notification) OnTaskRecognition(notifyType maa.NotificationType, detail maa.TaskRecognitionDetail) {
+}
+
+// OnTaskAction records the box of the recognition the action was taken on.
+// The node is only recorded once its action is done.
+func (n *notification) OnTaskAction(notifyType maa.NotificationType, detail maa.TaskActionDetail) {
+	if notifyType == maa.NotificationTypeStarting {
+		return
+	}
+	n.o.recordRecognition(detail.Name)
+}
+
+func (n *notification) OnUnknownNotification(msg, detailsJSON string) {
+}
@@ -3,17 +3,28 @@ package operator
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
-	"unsafe"
+	"sync"
+	"time"
 
 	"github.com/MaaXYZ/maa-framework-go"
 	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/debugbundle"
 	"github.com/dongwlin/elf-aid-magic/internal/gamemap"
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
+	"github.com/dongwlin/elf-aid-magic/internal/metrics"
 	"github.com/dongwlin/elf-aid-magic/internal/pipeline"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
+// ErrAlreadyRunning is returned when the tasker, the resource or the controllers are replaced during a run.
+var ErrAlreadyRunning = errors.New("operator is already running")
+
 type Operator struct {
 	ID      string
 	conf    *config.Config
@@ -22,32 +33,72 @@ type Operator struct {
 	tasker  *maa.Tasker
 	res     *maa.Resource
 	ctrl    maa.Controller
+
+	// ctrls holds the controllers of the tasker in priority order, ctrl is ctrls[active].
+	ctrls  []controller
+	active int
+
+	history *history.Store
+	lastRun *history.Run
+	bundles *debugbundle.Store
+
+	resLoaded bool
+
+	// lastError is the last error of the operator, for health checks.
+	lastError   string
+	lastErrorAt time.Time
+
+	// running is set while a run owns the controller, cancelRun cancels it.
+	running   bool
+	cancelRun context.CancelFunc
+
+	// lastBox is the box of the last recognition that hit, drawn over the screen stream.
+	lastBox *RecognitionBox
+
+	listeners    []EventListener
+	mutex        sync.Mutex
+	destroyMutex sync.Mutex
+	// inputMutex makes a manual input and the start of a run exclusive.
+	inputMutex sync.Mutex
 }
 
-func New(conf *config.Config, logger *zap.Logger, id string) *Operator {
+// New returns the operator of the tasker id, every log of the operator and its custom components carries the tasker id.
+func New(conf *config.Config, l *zap.Logger, id string) *Operator {
 	o := &Operator{
 		conf:   conf,
-		logger: logger,
+		logger: logger.ForTasker(l, id),
 		ID:     id,
 	}
 	o.init()
 	return o
 }
 
+// Destroy releases the tasker, the resource and the controllers. It is safe to call it more than once.
 func (o *Operator) Destroy() {
-	if o.ctrl != nil {
-		o.ctrl.Destroy()
-	}
-	if o.res != nil {
-		o.res.Destroy()
+	o.destroyMutex.Lock()
+	defer o.destroyMutex.Unlock()
+
+	o.destroyControllers()
+
+	o.mutex.Lock()
+	res, tasker := o.res, o.tasker
+	o.res = nil
+	o.tasker = nil
+	o.resLoaded = false
+	o.mutex.Unlock()
+
+	if res != nil {
+		res.Destroy()
 	}
-	if o.tasker != nil {
-		o.tasker.Destroy()
+	if tasker != nil {
+		tasker.Destroy()
 	}
 }
 
 func (o *Operator) init() {
 	o.initToolkit()
+	o.history = history.NewDefaultStore()
+	o.initDebugBundles()
 }
 
 func (o *Operator) initToolkit() {
@@ -61,12 +112,19 @@ func (o *Operator) InitTasker() bool {
 }
 
 func (o *Operator) initTasker() bool {
-	tasker := maa.NewTasker(nil)
+	if o.Running() {
+		o.logger.Error("failed to init tasker", zap.Error(ErrAlreadyRunning))
+		return false
+	}
+	tasker := maa.NewTasker(&notification{o: o})
 	if tasker == nil {
 		o.logger.Error("failed to init tasker.")
+		o.recordError("failed to init tasker")
 		return false
 	}
+	o.mutex.Lock()
 	o.tasker = tasker
+	o.mutex.Unlock()
 	return true
 }
 
@@ -75,18 +133,26 @@ func (o *Operator) InitResource() bool {
 }
 
 func (o *Operator) initResource() bool {
+	if o.Running() {
+		o.logger.Error("failed to init resource", zap.Error(ErrAlreadyRunning))
+		return false
+	}
 	res := maa.NewResource(nil)
 	if res == nil {
 		o.logger.Error("failed to init resource")
+		o.recordError("failed to init resource")
 		return false
 	}
+	o.mutex.Lock()
 	o.res = res
+	o.mutex.Unlock()
 	exePath, err := os.Executable()
 	if err != nil {
 		o.logger.Error(
 			"failed to get executable path",
 			zap.Error(err),
 		)
+		o.recordError("failed to get executable path")
 		return false
 	}
 	exeDir := filepath.Dir(exePath)
@@ -101,6 +167,7 @@ func (o *Operator) initResource() bool {
 			"failed to load resource",
 			zap.String("resource", resPath),
 		)
+		o.recordError("failed to load resource")
 		return false
 	}
 
@@ -110,221 +177,316 @@ func (o *Operator) initResource() bool {
 
 	if ok := o.tasker.BindResource(o.res); !ok {
 		o.logger.Error("failed to bind resource")
+		o.recordError("failed to bind resource")
 		return false
 	}
+	o.mutex.Lock()
+	o.resLoaded = true
+	o.mutex.Unlock()
 	return true
 }
 
-func (o *Operator) InitController() bool {
-	tasker, ok := o.getTaskerConfig()
-	if !ok {
-		return false
-	}
-
-	switch tasker.CtrlType {
-	case "adb":
-		return o.initAdbController()
-	case "win32":
-		return o.initWin32Controller()
-	default:
-		o.logger.Error(
-			"unknown ctrl type",
-			zap.String("ctrl type", tasker.CtrlType),
+// Run runs the tasks of the tasker from the first one, discarding any checkpoint left by a previous run.
+func (o *Operator) Run(ctx context.Context, trigger history.Trigger) bool {
+	if err := removeCheckpoint(o.ID); err != nil {
+		o.logger.Warn("failed to remove checkpoint",
+			zap.Error(err),
 		)
-		return false
 	}
-}
-
-func (o *Operator) initAdbController() bool {
 	tasker, ok := o.getTaskerConfig()
 	if !ok {
 		return false
 	}
-	device := tasker.AdbDevice
-
-	var adbConfigStr string
-	adbConfigData, err := json.Marshal(device.Config)
-	if err != nil {
-		o.logger.Error(
-			"failed to serialize adb config",
-			zap.Error(err),
-		)
-		return false
-	}
-	if device.Config == nil {
-		adbConfigStr = "{}"
-	} else {
-		adbConfigStr = string(adbConfigData)
-	}
-
-	o.logger.Info(
-		"adb config",
-		zap.String("config", adbConfigStr),
-	)
-
-	screencap := device.GetScreencapMethod()
-	if screencap == maa.AdbScreencapMethodNone {
-		o.logger.Error("invalid adb screencap method",
-			zap.String("adb screencap method", device.Screencap),
-		)
-		return false
-	}
+	return o.run(ctx, trigger, uuid.NewString(), tasker.Tasks, 0, false)
+}
 
-	input := device.GetInputMethod()
-	if screencap == maa.AdbScreencapMethod(maa.AdbInputMethodNone) {
-		o.logger.Error("invalid adb input method",
-			zap.String("adb input method", device.Screencap),
-		)
+// RunEntry runs the single pipeline entry with param, regardless of the tasks of the tasker.
+// It neither uses nor changes the checkpoint.
+func (o *Operator) RunEntry(ctx context.Context, trigger history.Trigger, entry string, param map[string]interface{}) bool {
+	if entry == "" {
+		o.logger.Error("entry is empty")
 		return false
 	}
-
-	ctrl := maa.NewAdbController(
-		o.conf.AdbPath,
-		device.SerialNumber,
-		screencap,
-		input,
-		adbConfigStr,
-		"./MaaAgentBinary",
-		nil,
-	)
-	if ctrl == nil {
-		o.logger.Error("failed to init adb controller")
-		return false
+	if param == nil {
+		param = map[string]interface{}{}
 	}
-	o.ctrl = ctrl
-	o.logger.Info(
-		"create adb controller",
-		zap.String("path", o.conf.AdbPath),
-		zap.String("address", device.SerialNumber),
-	)
-	if ok := o.tasker.BindController(o.ctrl); !ok {
-		o.logger.Error("failed to bind controller")
-		return false
+	tasks := []config.Task{
+		{Entry: entry, Param: param},
 	}
-	return true
+	return o.run(ctx, trigger, uuid.NewString(), tasks, 0, true)
 }
 
-func (o *Operator) initWin32Controller() bool {
-	tasker, ok := o.getTaskerConfig()
-	if !ok {
-		return false
-	}
-	window := tasker.Win32Window
-
-	windows := o.toolkit.FindDesktopWindows()
-	var handle unsafe.Pointer
-	for _, window := range windows {
-		if window.WindowName == "雷索纳斯" && window.ClassName == "UnityWndClass" {
-			handle = window.Handle
-			break
+// Resume continues the run recorded in the checkpoint after its last completed task.
+// Without a usable checkpoint, it starts over like Run.
+func (o *Operator) Resume(ctx context.Context, trigger history.Trigger) bool {
+	cp, err := loadCheckpoint(o.ID)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			o.logger.Warn("failed to load checkpoint",
+				zap.Error(err),
+			)
 		}
-	}
-	if handle == nil {
-		o.logger.Error("not found target window")
-		return false
+		o.logger.Info("no checkpoint to resume, start over")
+		return o.Run(ctx, trigger)
 	}
 
-	screencap := window.GetScreencapMethod()
-	if screencap == maa.Win32ScreencapMethodNone {
-		o.logger.Error("invalid win32 screencap method",
-			zap.String("win32 screencap method", window.Screencap),
-		)
+	tasker, ok := o.getTaskerConfig()
+	if !ok {
 		return false
 	}
-
-	input := window.GetInputMethod()
-	if input == maa.Win32InputMethodNone {
-		o.logger.Error("invalid win32 input method",
-			zap.String("win32 input method", window.Input),
+	if cp.Index < 0 || cp.Index >= len(tasker.Tasks) || tasker.Tasks[cp.Index].Entry != cp.Entry {
+		o.logger.Warn("checkpoint does not match the tasks, start over",
+			zap.String("run id", cp.RunID),
+			zap.Int("index", cp.Index),
+			zap.String("entry", cp.Entry),
 		)
-		return false
-	}
-
-	ctrl := maa.NewWin32Controller(handle, screencap, input, nil)
-	if ctrl == nil {
-		o.logger.Error("failed to init win32 controller")
-		return false
-	}
-	o.ctrl = ctrl
-	o.logger.Info("create win32 controller")
-	o.ctrl.SetScreenshotUseRawSize(true)
-	if ok := o.tasker.BindController(o.ctrl); !ok {
-		o.logger.Error("failed to bind controller")
-		return false
+		return o.Run(ctx, trigger)
 	}
-	return true
-}
 
-func (o *Operator) Connect() bool {
-	if !o.ctrl.PostConnect().Wait().Success() {
-		o.logger.Error("failed to connect")
-		return false
-	}
-	if !o.tasker.Initialized() {
-		o.logger.Error("failed to initialize tasker instance")
-		return false
-	}
-	return true
+	o.logger.Info("resume run",
+		zap.String("run id", cp.RunID),
+		zap.Int("index", cp.Index),
+		zap.String("entry", cp.Entry),
+	)
+	return o.run(ctx, trigger, cp.RunID, tasker.Tasks, cp.Index+1, false)
 }
 
-func (o *Operator) Run(ctx context.Context) bool {
+// run runs tasks from start. An ad hoc run does not checkpoint its progress.
+func (o *Operator) run(ctx context.Context, trigger history.Trigger, runID string, tasks []config.Task, start int, adHoc bool) bool {
 	if !o.tasker.Initialized() {
 		o.logger.Error("failed to initialize tasker instance")
 		return false
 	}
 
-	tasker, ok := o.getTaskerConfig()
-	if !ok {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	o.inputMutex.Lock()
+	o.mutex.Lock()
+	if o.running {
+		o.mutex.Unlock()
+		o.inputMutex.Unlock()
+		o.logger.Error("failed to start run", zap.Error(ErrAlreadyRunning))
 		return false
 	}
+	o.running = true
+	o.cancelRun = cancel
+	o.mutex.Unlock()
+	o.inputMutex.Unlock()
+	defer func() {
+		o.mutex.Lock()
+		o.running = false
+		o.cancelRun = nil
+		o.mutex.Unlock()
+	}()
+
+	record := &history.Run{
+		ID:        runID,
+		TaskerID:  o.ID,
+		Trigger:   trigger,
+		Resumed:   start > 0,
+		AdHoc:     adHoc,
+		Status:    history.StatusRunning,
+		StartedAt: time.Now(),
+		Tasks:     []history.TaskResult{},
+	}
+	runLogger := o.logger.With(zap.String(history.LogKeyRunID, runID))
+	runLogger.Info(history.LogMsgStartRun,
+		zap.String("trigger", string(trigger)),
+		zap.Int("tasks", len(tasks)),
+		zap.Int("start", start),
+		zap.Bool("ad hoc", adHoc),
+	)
+	metrics.RunsStarted.Inc(o.ID)
+	o.emit(EventRunStarted, *record)
 
-	for _, task := range tasker.Tasks {
+	failed := false
+	for i := start; i < len(tasks); i++ {
+		task := tasks[i]
 		select {
 		case <-ctx.Done():
-			o.logger.Info("operation cancelled")
+			runLogger.Info("operation cancelled")
+			o.finishRun(record, history.StatusInterrupted, "operation cancelled")
 			return false
 		default:
 		}
 
+		if !o.ensureConnected(ctx) {
+			runLogger.Error("no healthy controller to run tasks")
+			o.recordError("no healthy controller to run tasks")
+			if ctx.Err() != nil {
+				o.finishRun(record, history.StatusInterrupted, "operation cancelled")
+			} else {
+				o.finishRun(record, history.StatusFailed, "no healthy controller to run tasks")
+			}
+			return false
+		}
+
 		param, err := json.Marshal(task.Param)
 		if err != nil {
 			o.Destroy()
-			o.logger.Fatal(
+			runLogger.Fatal(
 				"failed to serialize task param",
 				zap.Error(err),
 			)
 		}
-		o.logger.Info(
-			"run task",
+		runLogger.Info(
+			history.LogMsgRunTask,
 			zap.String("entry", task.Entry),
 			zap.String("param", string(param)),
 		)
-		if ok := o.tasker.PostPipeline(task.Entry, string(param)).Wait().Success(); !ok {
-			o.logger.Error(
-				"failed to complete the task",
+		result := history.TaskResult{
+			Index:     i,
+			Entry:     task.Entry,
+			StartedAt: time.Now(),
+		}
+		job := o.tasker.PostPipeline(task.Entry, string(param)).Wait()
+		ok := job.Success()
+		if !ok && ctx.Err() == nil && !o.controllerHealthy() && o.ensureConnected(ctx) {
+			runLogger.Info(
+				history.LogMsgRetryTask,
+				zap.String("entry", task.Entry),
+			)
+			job = o.tasker.PostPipeline(task.Entry, string(param)).Wait()
+			ok = job.Success()
+		}
+		result.EndedAt = time.Now()
+		if !ok {
+			runLogger.Error(
+				history.LogMsgTaskFailed,
 				zap.String("entry", task.Entry),
 			)
-			select {
-			case <-ctx.Done():
-				o.logger.Info("operation cancelled")
+			if ctx.Err() != nil {
+				result.Status = history.StatusInterrupted
+				result.Error = "operation cancelled"
+				o.appendTaskResult(record, result)
+				runLogger.Info("operation cancelled")
+				o.finishRun(record, history.StatusInterrupted, "operation cancelled")
 				return false
-			default:
-				continue
 			}
+			failed = true
+			result.Status = history.StatusFailed
+			result.Error = "failed to complete the task"
+			o.recordError(fmt.Sprintf("failed to complete the task %s", task.Entry))
+			result.DebugBundle = o.saveDebugBundle(runID, task.Entry, string(param), result.Error, job)
+			o.appendTaskResult(record, result)
+			o.emit(EventTaskFailed, result)
+			continue
 		}
-		o.logger.Info(
-			"success to complete the task",
+		runLogger.Info(
+			history.LogMsgTaskSuccess,
 			zap.String("entry", task.Entry),
 		)
+		result.Status = history.StatusSuccess
+		o.appendTaskResult(record, result)
+		o.emit(EventTaskCompleted, result)
+		if adHoc {
+			continue
+		}
+		err = saveCheckpoint(&Checkpoint{
+			RunID:     runID,
+			TaskerID:  o.ID,
+			Index:     i,
+			Entry:     task.Entry,
+			UpdatedAt: time.Now(),
+		})
+		if err != nil {
+			runLogger.Warn("failed to save checkpoint",
+				zap.Error(err),
+			)
+		}
+	}
+	runLogger.Info("complete all tasks")
+	if !adHoc {
+		if err := removeCheckpoint(o.ID); err != nil {
+			runLogger.Warn("failed to remove checkpoint",
+				zap.Error(err),
+			)
+		}
+	}
+	if failed {
+		o.finishRun(record, history.StatusFailed, "some tasks failed")
+	} else {
+		o.finishRun(record, history.StatusSuccess, "")
 	}
-	o.logger.Info("complete all tasks")
 	return true
 }
 
+// appendTaskResult adds result to the run and observes its duration.
+func (o *Operator) appendTaskResult(record *history.Run, result history.TaskResult) {
+	record.Tasks = append(record.Tasks, result)
+	metrics.TaskDuration.Observe(result.EndedAt.Sub(result.StartedAt).Seconds(), result.Entry, result.Status)
+}
+
+// finishRun records the run in the history and emits run_completed.
+func (o *Operator) finishRun(record *history.Run, status, errMsg string) {
+	record.Status = status
+	record.Error = errMsg
+	record.EndedAt = time.Now()
+
+	o.logger.Info(history.LogMsgFinishRun,
+		zap.String(history.LogKeyRunID, record.ID),
+		zap.String("status", status),
+		zap.String("error", errMsg),
+		zap.Duration("duration", record.EndedAt.Sub(record.StartedAt)),
+	)
+
+	switch status {
+	case history.StatusSuccess:
+		metrics.RunsCompleted.Inc(o.ID)
+		o.clearError()
+	case history.StatusInterrupted:
+		metrics.RunsInterrupted.Inc(o.ID)
+	default:
+		metrics.RunsFailed.Inc(o.ID)
+	}
+
+	o.mutex.Lock()
+	o.lastRun = record
+	o.mutex.Unlock()
+
+	if err := o.history.Append(record); err != nil {
+		o.logger.Warn("failed to record run history",
+			zap.String("run id", record.ID),
+			zap.Error(err),
+		)
+	}
+	o.emit(EventRunCompleted, *record)
+}
+
+// Running reports whether a run of the operator is in progress.
+func (o *Operator) Running() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.running
+}
+
+// LastRun returns the record of the last finished run, or nil if the operator has not run yet.
+func (o *Operator) LastRun() *history.Run {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return o.lastRun
+}
+
 func (o *Operator) Stop() *maa.TaskJob {
 	return o.tasker.PostStop()
 }
 
+// StopRun cancels the run in progress and posts a stop to the tasker without waiting for it.
+// It reports whether a run was in progress.
+func (o *Operator) StopRun() bool {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if !o.running {
+		return false
+	}
+	// The run holds the tasker until it returns, which needs the mutex held here.
+	o.cancelRun()
+	if o.tasker != nil {
+		o.tasker.PostStop()
+	}
+	return true
+}
+
 func (o *Operator) getTaskerConfig() (*config.TaskerConfig, bool) {
 	taskers := o.conf.Taskers
 	if len(taskers) == 0 {
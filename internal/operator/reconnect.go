@@ -0,0 +1,107 @@
+package operator
+
+import (
+	"context"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/metrics"
+	"go.uber.org/zap"
+)
+
+// StartupEntry is the pipeline entry that brings the game back to a known state after reconnecting.
+const StartupEntry = "Startup"
+
+// minReconnectDelay is the first delay when initial_delay is not positive, so that the delay still doubles.
+const minReconnectDelay = time.Second
+
+type ReconnectingData struct {
+	Attempt     int    `json:"attempt"`
+	MaxAttempts int    `json:"max_attempts"`
+	Delay       string `json:"delay"`
+}
+
+type ReconnectedData struct {
+	Attempts int `json:"attempts"`
+}
+
+// ensureConnected checks the health of the controller between tasks.
+// An unhealthy controller is replaced by the next one if possible, otherwise reconnected with exponential backoff.
+// After recovering, the Startup entry is run before the remaining tasks.
+func (o *Operator) ensureConnected(ctx context.Context) bool {
+	if o.controllerHealthy() {
+		return true
+	}
+	if !o.failover("screencap failed") && !o.reconnect(ctx) {
+		return false
+	}
+	o.startup()
+	return true
+}
+
+func (o *Operator) reconnect(ctx context.Context) bool {
+	conf := o.conf.Reconnect
+	o.logger.Warn("controller disconnected")
+	o.emit(EventDisconnected, nil)
+
+	delay := time.Duration(conf.InitialDelay) * time.Second
+	if delay < minReconnectDelay {
+		delay = minReconnectDelay
+	}
+	maxDelay := time.Duration(conf.MaxDelay) * time.Second
+	if maxDelay < delay {
+		maxDelay = delay
+	}
+	for attempt := 1; attempt <= conf.MaxAttempts; attempt++ {
+		o.logger.Info("reconnect",
+			zap.Int("attempt", attempt),
+			zap.Int("max attempts", conf.MaxAttempts),
+			zap.Duration("delay", delay),
+		)
+		o.emit(EventReconnecting, ReconnectingData{
+			Attempt:     attempt,
+			MaxAttempts: conf.MaxAttempts,
+			Delay:       delay.String(),
+		})
+
+		select {
+		case <-ctx.Done():
+			o.logger.Info("reconnect cancelled")
+			return false
+		case <-time.After(delay):
+		}
+
+		if o.Connect() && o.controllerHealthy() {
+			metrics.Reconnects.Inc(o.ID, metrics.ResultSuccess)
+			o.logger.Info("reconnected",
+				zap.Int("attempts", attempt),
+			)
+			o.emit(EventReconnected, ReconnectedData{
+				Attempts: attempt,
+			})
+			return true
+		}
+
+		delay *= 2
+		if delay > maxDelay {
+			delay = maxDelay
+		}
+	}
+
+	metrics.Reconnects.Inc(o.ID, metrics.ResultFailure)
+	o.logger.Error("failed to reconnect",
+		zap.Int("attempts", conf.MaxAttempts),
+	)
+	o.emit(EventReconnectFailed, nil)
+	return false
+}
+
+func (o *Operator) startup() {
+	o.logger.Info("run startup entry",
+		zap.String("entry", StartupEntry),
+	)
+	if ok := o.tasker.PostPipeline(StartupEntry).Wait().Success(); !ok {
+		o.logger.Warn("failed to complete the startup entry",
+			zap.String("entry", StartupEntry),
+		)
+	}
+}
@@ -0,0 +1,44 @@
+package operator
+
+import (
+	"image"
+	"time"
+)
+
+type RecognitionBox struct {
+	Name string
+	Box  image.Rectangle
+	Time time.Time
+}
+
+// Screen returns the last screencap of the active controller and the box of the last recognition that hit.
+// It never takes a screencap itself, so the image is only as recent as the last one taken by the tasks.
+// The image is nil if no controller is connected.
+func (o *Operator) Screen() (image.Image, *RecognitionBox) {
+	img := o.cacheImage()
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	return img, o.lastBox
+}
+
+func (o *Operator) recordRecognition(name string) {
+	o.mutex.Lock()
+	tasker := o.tasker
+	o.mutex.Unlock()
+	if tasker == nil {
+		return
+	}
+	node := tasker.GetLatestNode(name)
+	if node == nil || node.Recognition == nil || !node.Recognition.Hit {
+		return
+	}
+	box := node.Recognition.Box
+	o.mutex.Lock()
+	o.lastBox = &RecognitionBox{
+		Name: name,
+		Box:  image.Rect(int(box.X), int(box.Y), int(box.X+box.W), int(box.Y+box.H)),
+		Time: time.Now(),
+	}
+	o.mutex.Unlock()
+}
@@ -0,0 +1,51 @@
+package operator
+
+import "time"
+
+// Status is a snapshot of the state of an operator, for health checks.
+type Status struct {
+	TaskerID       string     `json:"tasker_id"`
+	Running        bool       `json:"running"`
+	ResourceLoaded bool       `json:"resource_loaded"`
+	Connected      bool       `json:"connected"`
+	Controller     string     `json:"controller,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time `json:"last_error_at,omitempty"`
+}
+
+func (o *Operator) Status() Status {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	status := Status{
+		TaskerID:       o.ID,
+		Running:        o.running,
+		ResourceLoaded: o.resLoaded,
+		Connected:      o.ctrl != nil && o.ctrl.Connected(),
+		LastError:      o.lastError,
+	}
+	if o.ctrl != nil {
+		status.Controller = o.ctrls[o.active].ctrlType
+	}
+	if !o.lastErrorAt.IsZero() {
+		lastErrorAt := o.lastErrorAt
+		status.LastErrorAt = &lastErrorAt
+	}
+	return status
+}
+
+// recordError keeps msg as the last error until the operator recovers, see clearError.
+func (o *Operator) recordError(msg string) {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.lastError = msg
+	o.lastErrorAt = time.Now()
+}
+
+// clearError forgets the last error, once a run succeeds or a controller is connected again.
+func (o *Operator) clearError() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	o.lastError = ""
+	o.lastErrorAt = time.Time{}
+}
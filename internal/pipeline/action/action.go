@@ -4,10 +4,35 @@ import (
 	"github.com/MaaXYZ/maa-framework-go"
 	"github.com/dongwlin/elf-aid-magic/internal/config"
 	"github.com/dongwlin/elf-aid-magic/internal/gamemap"
+	"github.com/dongwlin/elf-aid-magic/internal/metrics"
 	"go.uber.org/zap"
 )
 
 func Register(res *maa.Resource, conf *config.Config, logger *zap.Logger, taskerID string, navAsst *gamemap.NavigationAssistant) {
-	res.RegisterCustomAction("SetCurrentLocation", NewSetCurrentLocationAction(logger, navAsst))
-	res.RegisterCustomAction("MapNavigation", NewMapNavigationAction(logger, navAsst))
+	register(res, "SetCurrentLocation", NewSetCurrentLocationAction(logger, navAsst))
+	register(res, "MapNavigation", NewMapNavigationAction(logger, navAsst))
+}
+
+// register registers action as name, counting its runs in the metrics.
+func register(res *maa.Resource, name string, action maa.CustomAction) {
+	res.RegisterCustomAction(name, &countedAction{
+		name:   name,
+		action: action,
+	})
+}
+
+type countedAction struct {
+	name   string
+	action maa.CustomAction
+}
+
+// Run implements maa.CustomAction.
+func (a *countedAction) Run(ctx *maa.Context, arg *maa.CustomActionArg) bool {
+	ok := a.action.Run(ctx, arg)
+	result := metrics.ResultSuccess
+	if !ok {
+		result = metrics.ResultFailure
+	}
+	metrics.CustomActions.Inc(a.name, result)
+	return ok
 }
@@ -55,7 +55,7 @@ func (i *IsAppInactiveRecognition) Run(ctx *maa.Context, arg *maa.CustomRecognit
 	if err != nil {
 		return nil, false
 	}
-	actived, err := adbtool.IsAppActive(i.conf.AdbPath, device.SerialNumber, param.Package)
+	actived, err := adbtool.IsAppActive(i.conf.GetAdbPath(tasker), device.SerialNumber, param.Package)
 	if err != nil {
 		return nil, false
 	}
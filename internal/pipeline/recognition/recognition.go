@@ -3,6 +3,7 @@ package recognition
 import (
 	"github.com/MaaXYZ/maa-framework-go"
 	"github.com/dongwlin/elf-aid-magic/internal/config"
+	"github.com/dongwlin/elf-aid-magic/internal/metrics"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +20,30 @@ type OCRDetailItem struct {
 }
 
 func Register(res *maa.Resource, conf *config.Config, logger *zap.Logger, taskerID string) {
-	res.RegisterCustomRecognition("UseRapidProjectile", NewUseRapidProjectileRecogniation())
-	res.RegisterCustomRecognition("IsAppInactive", NewIsAppInactiveRecognition(conf, logger, taskerID))
+	register(res, "UseRapidProjectile", NewUseRapidProjectileRecogniation())
+	register(res, "IsAppInactive", NewIsAppInactiveRecognition(conf, logger, taskerID))
+}
+
+// register registers recognition as name, counting its runs in the metrics.
+func register(res *maa.Resource, name string, recognition maa.CustomRecognition) {
+	res.RegisterCustomRecognition(name, &countedRecognition{
+		name:        name,
+		recognition: recognition,
+	})
+}
+
+type countedRecognition struct {
+	name        string
+	recognition maa.CustomRecognition
+}
+
+// Run implements maa.CustomRecognition.
+func (r *countedRecognition) Run(ctx *maa.Context, arg *maa.CustomRecognitionArg) (*maa.CustomRecognitionResult, bool) {
+	result, hit := r.recognition.Run(ctx, arg)
+	label := metrics.ResultHit
+	if !hit {
+		label = metrics.ResultMiss
+	}
+	metrics.CustomRecognitions.Inc(r.name, label)
+	return result, hit
 }
@@ -0,0 +1,56 @@
+package mjpeg
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+)
+
+const Boundary = "frame"
+
+// ContentType is the content type of an MJPEG stream written with WriteFrame.
+const ContentType = "multipart/x-mixed-replace; boundary=" + Boundary
+
+// WriteFrame writes img as one JPEG part of an MJPEG stream.
+func WriteFrame(w io.Writer, img image.Image, quality int) error {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", Boundary, buf.Len())
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\r\n")
+	return err
+}
+
+// DrawRect returns a copy of img with the outline of rect drawn on it, clipped to the bounds of img.
+func DrawRect(img image.Image, rect image.Rectangle, c color.Color, thickness int) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+
+	rect = rect.Canon()
+	if rect.Empty() || thickness <= 0 {
+		return dst
+	}
+	src := image.NewUniform(c)
+	edges := []image.Rectangle{
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Max.X, rect.Min.Y+thickness),
+		image.Rect(rect.Min.X, rect.Max.Y-thickness, rect.Max.X, rect.Max.Y),
+		image.Rect(rect.Min.X, rect.Min.Y, rect.Min.X+thickness, rect.Max.Y),
+		image.Rect(rect.Max.X-thickness, rect.Min.Y, rect.Max.X, rect.Max.Y),
+	}
+	for _, edge := range edges {
+		draw.Draw(dst, edge.Intersect(bounds), src, image.Point{}, draw.Src)
+	}
+	return dst
+}
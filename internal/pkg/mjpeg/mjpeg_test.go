@@ -0,0 +1,51 @@
+package mjpeg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"mime/multipart"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteFrame(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 9))
+
+	var buf bytes.Buffer
+	for i := 0; i < 2; i++ {
+		require.NoError(t, WriteFrame(&buf, img, 75))
+	}
+
+	reader := multipart.NewReader(&buf, Boundary)
+	for i := 0; i < 2; i++ {
+		part, err := reader.NextPart()
+		require.NoError(t, err)
+		require.Equal(t, "image/jpeg", part.Header.Get("Content-Type"))
+		frame, err := jpeg.Decode(part)
+		require.NoError(t, err)
+		require.Equal(t, img.Bounds(), frame.Bounds())
+	}
+	_, err := reader.NextPart()
+	require.ErrorIs(t, err, io.EOF)
+}
+
+func TestDrawRect(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 10, 10))
+
+	dst := DrawRect(img, image.Rect(2, 2, 6, 6), red, 1)
+	require.Equal(t, red, dst.RGBAAt(2, 2))
+	require.Equal(t, red, dst.RGBAAt(5, 3))
+	require.Equal(t, red, dst.RGBAAt(3, 5))
+	require.Equal(t, color.RGBA{}, dst.RGBAAt(3, 3))
+	require.Equal(t, color.RGBA{}, dst.RGBAAt(6, 6))
+	require.Equal(t, color.RGBA{}, img.RGBAAt(2, 2), "the source image should not be modified")
+
+	dst = DrawRect(img, image.Rect(8, 8, 20, 20), red, 2)
+	require.Equal(t, red, dst.RGBAAt(9, 8))
+	require.Equal(t, color.RGBA{}, dst.RGBAAt(7, 9))
+}
@@ -0,0 +1,87 @@
+package pidfile
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var ErrLocked = errors.New("pid file is locked by another process")
+
+// PidFile is a pid file owned by the current process.
+// Ownership is an exclusive lock on a lock file next to it, which the system releases when the process exits,
+// so a pid file without a lock was left by a process that is gone.
+type PidFile struct {
+	path string
+	lock *os.File
+}
+
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// Acquire locks the pid file at path and writes the pid of the current process to it.
+// It returns ErrLocked if another process owns the pid file.
+func Acquire(path string) (*PidFile, error) {
+	lock, err := os.OpenFile(lockPath(path), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(lock); err != nil {
+		lock.Close()
+		return nil, err
+	}
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())), 0600); err != nil {
+		unlockFile(lock)
+		lock.Close()
+		return nil, err
+	}
+	return &PidFile{
+		path: path,
+		lock: lock,
+	}, nil
+}
+
+// Release removes the pid file and unlocks it.
+func (p *PidFile) Release() error {
+	err := os.Remove(p.path)
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+	}
+	unlockFile(p.lock)
+	if cerr := p.lock.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Read returns the pid in the pid file at path.
+func Read(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// Locked reports whether a process owns the pid file at path.
+func Locked(path string) (bool, error) {
+	lock, err := os.OpenFile(lockPath(path), os.O_RDWR, 0600)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		if errors.Is(err, ErrLocked) {
+			return true, nil
+		}
+		return false, err
+	}
+	unlockFile(lock)
+	return false, nil
+}
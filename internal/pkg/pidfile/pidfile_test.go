@@ -0,0 +1,59 @@
+package pidfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pid")
+
+	locked, err := Locked(path)
+	require.NoError(t, err)
+	require.False(t, locked)
+
+	p, err := Acquire(path)
+	require.NoError(t, err)
+
+	pid, err := Read(path)
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), pid)
+
+	locked, err = Locked(path)
+	require.NoError(t, err)
+	require.True(t, locked)
+
+	_, err = Acquire(path)
+	require.ErrorIs(t, err, ErrLocked)
+
+	require.NoError(t, p.Release())
+	_, err = Read(path)
+	require.True(t, os.IsNotExist(err))
+
+	locked, err = Locked(path)
+	require.NoError(t, err)
+	require.False(t, locked)
+}
+
+func TestStalePidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pid")
+	require.NoError(t, os.WriteFile(path, []byte("12345\n"), 0600))
+
+	pid, err := Read(path)
+	require.NoError(t, err)
+	require.Equal(t, 12345, pid)
+
+	locked, err := Locked(path)
+	require.NoError(t, err)
+	require.False(t, locked, "a pid file without a lock is stale")
+
+	p, err := Acquire(path)
+	require.NoError(t, err)
+	pid, err = Read(path)
+	require.NoError(t, err)
+	require.Equal(t, os.Getpid(), pid)
+	require.NoError(t, p.Release())
+}
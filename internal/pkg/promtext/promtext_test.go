@@ -0,0 +1,48 @@
+package promtext
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	runs := r.NewCounterVec("eam_runs_started_total", "Runs started.", "tasker_id")
+	clients := r.NewGauge("eam_websocket_clients", "Connected clients.")
+	durations := r.NewHistogramVec("eam_task_duration_seconds", "Task durations.", []float64{10, 1}, "entry")
+
+	runs.Inc("b")
+	runs.Inc("a")
+	runs.Add(2, "a")
+	runs.Inc(`say "hi"`)
+	clients.Set(2)
+	durations.Observe(0.5, "Startup")
+	durations.Observe(3, "Startup")
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+	require.Equal(t, `# HELP eam_runs_started_total Runs started.
+# TYPE eam_runs_started_total counter
+eam_runs_started_total{tasker_id="a"} 3
+eam_runs_started_total{tasker_id="b"} 1
+eam_runs_started_total{tasker_id="say \"hi\""} 1
+# HELP eam_websocket_clients Connected clients.
+# TYPE eam_websocket_clients gauge
+eam_websocket_clients 2
+# HELP eam_task_duration_seconds Task durations.
+# TYPE eam_task_duration_seconds histogram
+eam_task_duration_seconds_bucket{entry="Startup",le="1"} 1
+eam_task_duration_seconds_bucket{entry="Startup",le="10"} 2
+eam_task_duration_seconds_bucket{entry="Startup",le="+Inf"} 2
+eam_task_duration_seconds_sum{entry="Startup"} 3.5
+eam_task_duration_seconds_count{entry="Startup"} 2
+`, buf.String())
+}
+
+func TestLabelCountMismatch(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("c", "c", "a", "b")
+	require.Panics(t, func() { c.Inc("only one") })
+}
@@ -0,0 +1,93 @@
+package winmatch
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Match modes of a Pattern.
+const (
+	ModeExact    = "exact"
+	ModeContains = "contains"
+	ModeRegex    = "regex"
+)
+
+var ErrNoMatch = errors.New("no window matched")
+
+// Pattern matches a window name or class name. An empty Value matches everything.
+type Pattern struct {
+	Value string
+	// Mode is one of ModeExact, ModeContains and ModeRegex, defaulting to ModeExact.
+	Mode string
+}
+
+// Criteria selects the Index-th window, counting from 0, whose name and class name both match.
+type Criteria struct {
+	WindowName Pattern
+	ClassName  Pattern
+	Index      int
+}
+
+// Window is the platform independent part of a desktop window.
+type Window struct {
+	WindowName string
+	ClassName  string
+}
+
+// Find returns the position in windows of the window selected by c.
+func Find(windows []Window, c Criteria) (int, error) {
+	if c.Index < 0 {
+		return -1, fmt.Errorf("invalid window index %d", c.Index)
+	}
+	matchName, err := c.WindowName.matcher()
+	if err != nil {
+		return -1, fmt.Errorf("invalid window name pattern: %w", err)
+	}
+	matchClass, err := c.ClassName.matcher()
+	if err != nil {
+		return -1, fmt.Errorf("invalid class name pattern: %w", err)
+	}
+
+	matched := 0
+	for i, window := range windows {
+		if !matchName(window.WindowName) || !matchClass(window.ClassName) {
+			continue
+		}
+		if matched == c.Index {
+			return i, nil
+		}
+		matched++
+	}
+	if matched == 0 {
+		return -1, ErrNoMatch
+	}
+	return -1, fmt.Errorf("%w: %d windows matched, but index is %d", ErrNoMatch, matched, c.Index)
+}
+
+// Validate reports whether the mode of p is known and its value compiles.
+func (p Pattern) Validate() error {
+	_, err := p.matcher()
+	return err
+}
+
+func (p Pattern) matcher() (func(string) bool, error) {
+	if p.Value == "" {
+		return func(string) bool { return true }, nil
+	}
+	switch p.Mode {
+	case "", ModeExact:
+		return func(s string) bool { return s == p.Value }, nil
+	case ModeContains:
+		return func(s string) bool { return strings.Contains(s, p.Value) }, nil
+	case ModeRegex:
+		re, err := regexp.Compile(p.Value)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	default:
+		return nil, fmt.Errorf("unknown match mode %q", p.Mode)
+	}
+}
@@ -0,0 +1,120 @@
+package winmatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	windows := []Window{
+		{WindowName: "Program Manager", ClassName: "Progman"},
+		{WindowName: "雷索纳斯", ClassName: "UnityWndClass"},
+		{WindowName: "Resonance Solstice", ClassName: "UnityWndClass"},
+		{WindowName: "Resonance Solstice (2)", ClassName: "UnityWndClass"},
+	}
+
+	testCases := []struct {
+		Name         string
+		Criteria     Criteria
+		ExpectIndex  int
+		ExpectErr    error
+		ExpectAnyErr bool
+	}{
+		{
+			Name: "Exact",
+			Criteria: Criteria{
+				WindowName: Pattern{Value: "雷索纳斯"},
+				ClassName:  Pattern{Value: "UnityWndClass", Mode: ModeExact},
+			},
+			ExpectIndex: 1,
+		},
+		{
+			Name: "Contains",
+			Criteria: Criteria{
+				WindowName: Pattern{Value: "Resonance", Mode: ModeContains},
+			},
+			ExpectIndex: 2,
+		},
+		{
+			Name: "Contains With Index",
+			Criteria: Criteria{
+				WindowName: Pattern{Value: "Resonance", Mode: ModeContains},
+				Index:      1,
+			},
+			ExpectIndex: 3,
+		},
+		{
+			Name: "Regex",
+			Criteria: Criteria{
+				WindowName: Pattern{Value: `^(雷索纳斯|Resonance Solstice)$`, Mode: ModeRegex},
+				ClassName:  Pattern{Value: "UnityWndClass"},
+				Index:      1,
+			},
+			ExpectIndex: 2,
+		},
+		{
+			Name: "Class Name Only",
+			Criteria: Criteria{
+				ClassName: Pattern{Value: "Unity", Mode: ModeContains},
+			},
+			ExpectIndex: 1,
+		},
+		{
+			Name: "No Match",
+			Criteria: Criteria{
+				WindowName: Pattern{Value: "Notepad"},
+			},
+			ExpectIndex: -1,
+			ExpectErr:   ErrNoMatch,
+		},
+		{
+			Name: "Index Out Of Range",
+			Criteria: Criteria{
+				WindowName: Pattern{Value: "雷索纳斯"},
+				Index:      1,
+			},
+			ExpectIndex: -1,
+			ExpectErr:   ErrNoMatch,
+		},
+		{
+			Name: "Negative Index",
+			Criteria: Criteria{
+				Index: -1,
+			},
+			ExpectIndex:  -1,
+			ExpectAnyErr: true,
+		},
+		{
+			Name: "Invalid Regex",
+			Criteria: Criteria{
+				WindowName: Pattern{Value: "(", Mode: ModeRegex},
+			},
+			ExpectIndex:  -1,
+			ExpectAnyErr: true,
+		},
+		{
+			Name: "Unknown Mode",
+			Criteria: Criteria{
+				ClassName: Pattern{Value: "UnityWndClass", Mode: "glob"},
+			},
+			ExpectIndex:  -1,
+			ExpectAnyErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			index, err := Find(windows, tc.Criteria)
+			switch {
+			case tc.ExpectErr != nil:
+				require.ErrorIs(t, err, tc.ExpectErr)
+			case tc.ExpectAnyErr:
+				require.Error(t, err)
+			default:
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.ExpectIndex, index)
+		})
+	}
+}
@@ -4,7 +4,9 @@
 package wire
 
 import (
+	"github.com/dongwlin/elf-aid-magic/internal/config"
 	"github.com/dongwlin/elf-aid-magic/internal/handler"
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
 	"github.com/dongwlin/elf-aid-magic/internal/logic"
 	"github.com/dongwlin/elf-aid-magic/internal/operator"
 	"github.com/google/wire"
@@ -12,40 +14,85 @@ import (
 )
 
 var logicSet = wire.NewSet(
+	logic.NewBundleLogic,
+	logic.NewDeviceLogic,
+	logic.NewHealthLogic,
+	logic.NewInputLogic,
+	logic.NewLogLogic,
+	logic.NewMetricsLogic,
 	logic.NewPidLogic,
+	logic.NewRunLogic,
+	logic.NewShutdownLogic,
+	logic.NewStreamLogic,
 	logic.NewVersionLogic,
 	logic.NewWebSocketLogic,
 )
 
 var handlerSet = wire.NewSet(
+	handler.NewBundleHandler,
+	handler.NewDeviceHandler,
+	handler.NewHealthHandler,
+	handler.NewInputHandler,
+	handler.NewLogHandler,
+	handler.NewMetricsHandler,
 	handler.NewPidHandler,
 	handler.NewPingHandler,
+	handler.NewRunHandler,
+	handler.NewShutdownHandler,
+	handler.NewStreamHandler,
 	handler.NewVersionHandler,
 	handler.NewWebSocketHandler,
 )
 
 type Handler struct {
+	Bundle    *handler.BundleHandler
+	Device    *handler.DeviceHandler
+	Health    *handler.HealthHandler
+	Input     *handler.InputHandler
+	Log       *handler.LogHandler
+	Metrics   *handler.MetricsHandler
 	Pid       *handler.PidHandler
 	Ping      *handler.PingHandler
+	Run       *handler.RunHandler
+	Shutdown  *handler.ShutdownHandler
+	Stream    *handler.StreamHandler
 	Vesrion   *handler.VersionHandler
 	WebSocket *handler.WebSocketHandler
 }
 
 func provideHandler(
+	bundleHandler *handler.BundleHandler,
+	deviceHandler *handler.DeviceHandler,
+	healthHandler *handler.HealthHandler,
+	inputHandler *handler.InputHandler,
+	logHandler *handler.LogHandler,
+	metricsHandler *handler.MetricsHandler,
 	pidHandler *handler.PidHandler,
 	pingHandler *handler.PingHandler,
+	runHandler *handler.RunHandler,
+	shutdownHandler *handler.ShutdownHandler,
+	streamHandler *handler.StreamHandler,
 	versionHandler *handler.VersionHandler,
 	webSocketHandler *handler.WebSocketHandler,
 ) *Handler {
 	return &Handler{
+		Bundle:    bundleHandler,
+		Device:    deviceHandler,
+		Health:    healthHandler,
+		Input:     inputHandler,
+		Log:       logHandler,
+		Metrics:   metricsHandler,
 		Pid:       pidHandler,
 		Ping:      pingHandler,
+		Run:       runHandler,
+		Shutdown:  shutdownHandler,
+		Stream:    streamHandler,
 		Vesrion:   versionHandler,
 		WebSocket: webSocketHandler,
 	}
 }
 
-func InitHandler(logger *zap.Logger, om *operator.Manager) *Handler {
+func InitHandler(conf *config.Config, logger *zap.Logger, om *operator.Manager, logStream *logger.Stream, logLevel zap.AtomicLevel) *Handler {
 	wire.Build(logicSet, handlerSet, provideHandler)
 	return nil
 }
@@ -7,7 +7,9 @@
 package wire
 
 import (
+	"github.com/dongwlin/elf-aid-magic/internal/config"
 	"github.com/dongwlin/elf-aid-magic/internal/handler"
+	"github.com/dongwlin/elf-aid-magic/internal/logger"
 	"github.com/dongwlin/elf-aid-magic/internal/logic"
 	"github.com/dongwlin/elf-aid-magic/internal/operator"
 	"github.com/google/wire"
@@ -16,40 +18,85 @@ import (
 
 // Injectors from wire.go:
 
-func InitHandler(logger *zap.Logger, om *operator.Manager) *Handler {
+func InitHandler(conf *config.Config, logger *zap.Logger, om *operator.Manager, logStream *logger.Stream, logLevel zap.AtomicLevel) *Handler {
+	bundleLogic := logic.NewBundleLogic()
+	bundleHandler := handler.NewBundleHandler(logger, bundleLogic)
+	deviceLogic := logic.NewDeviceLogic(conf)
+	deviceHandler := handler.NewDeviceHandler(logger, deviceLogic)
+	shutdownLogic := logic.NewShutdownLogic()
+	healthLogic := logic.NewHealthLogic(om, shutdownLogic)
+	healthHandler := handler.NewHealthHandler(healthLogic)
+	inputLogic := logic.NewInputLogic(om)
+	inputHandler := handler.NewInputHandler(logger, inputLogic)
+	logLogic := logic.NewLogLogic(logger, logLevel)
+	logHandler := handler.NewLogHandler(logger, logLogic)
+	metricsLogic := logic.NewMetricsLogic()
+	metricsHandler := handler.NewMetricsHandler(logger, metricsLogic)
 	pidLogic := logic.NewPidLogic()
 	pidHandler := handler.NewPidHandler(pidLogic)
 	pingHandler := handler.NewPingHandler()
+	runLogic := logic.NewRunLogic()
+	runHandler := handler.NewRunHandler(logger, runLogic)
+	shutdownHandler := handler.NewShutdownHandler(logger, shutdownLogic)
+	streamLogic := logic.NewStreamLogic(conf, om)
+	streamHandler := handler.NewStreamHandler(logger, streamLogic)
 	versionLogic := logic.NewVersionLogic()
 	versionHandler := handler.NewVersionHandler(logger, versionLogic)
-	websocketLogic := logic.NewWebSocketLogic(logger, om)
+	websocketLogic := logic.NewWebSocketLogic(logger, om, logStream, logLogic)
 	webSocketHandler := handler.NewWebSocketHandler(logger, websocketLogic)
-	wireHandler := provideHandler(pidHandler, pingHandler, versionHandler, webSocketHandler)
+	wireHandler := provideHandler(bundleHandler, deviceHandler, healthHandler, inputHandler, logHandler, metricsHandler, pidHandler, pingHandler, runHandler, shutdownHandler, streamHandler, versionHandler, webSocketHandler)
 	return wireHandler
 }
 
 // wire.go:
 
-var logicSet = wire.NewSet(logic.NewPidLogic, logic.NewVersionLogic, logic.NewWebSocketLogic)
+var logicSet = wire.NewSet(logic.NewBundleLogic, logic.NewDeviceLogic, logic.NewHealthLogic, logic.NewInputLogic, logic.NewLogLogic, logic.NewMetricsLogic, logic.NewPidLogic, logic.NewRunLogic, logic.NewShutdownLogic, logic.NewStreamLogic, logic.NewVersionLogic, logic.NewWebSocketLogic)
 
-var handlerSet = wire.NewSet(handler.NewPidHandler, handler.NewPingHandler, handler.NewVersionHandler, handler.NewWebSocketHandler)
+var handlerSet = wire.NewSet(handler.NewBundleHandler, handler.NewDeviceHandler, handler.NewHealthHandler, handler.NewInputHandler, handler.NewLogHandler, handler.NewMetricsHandler, handler.NewPidHandler, handler.NewPingHandler, handler.NewRunHandler, handler.NewShutdownHandler, handler.NewStreamHandler, handler.NewVersionHandler, handler.NewWebSocketHandler)
 
 type Handler struct {
+	Bundle    *handler.BundleHandler
+	Device    *handler.DeviceHandler
+	Health    *handler.HealthHandler
+	Input     *handler.InputHandler
+	Log       *handler.LogHandler
+	Metrics   *handler.MetricsHandler
 	Pid       *handler.PidHandler
 	Ping      *handler.PingHandler
+	Run       *handler.RunHandler
+	Shutdown  *handler.ShutdownHandler
+	Stream    *handler.StreamHandler
 	Vesrion   *handler.VersionHandler
 	WebSocket *handler.WebSocketHandler
 }
 
 func provideHandler(
+	bundleHandler *handler.BundleHandler,
+	deviceHandler *handler.DeviceHandler,
+	healthHandler *handler.HealthHandler,
+	inputHandler *handler.InputHandler,
+	logHandler *handler.LogHandler,
+	metricsHandler *handler.MetricsHandler,
 	pidHandler *handler.PidHandler,
 	pingHandler *handler.PingHandler,
+	runHandler *handler.RunHandler,
+	shutdownHandler *handler.ShutdownHandler,
+	streamHandler *handler.StreamHandler,
 	versionHandler *handler.VersionHandler,
 	webSocketHandler *handler.WebSocketHandler,
 ) *Handler {
 	return &Handler{
+		Bundle:    bundleHandler,
+		Device:    deviceHandler,
+		Health:    healthHandler,
+		Input:     inputHandler,
+		Log:       logHandler,
+		Metrics:   metricsHandler,
 		Pid:       pidHandler,
 		Ping:      pingHandler,
+		Run:       runHandler,
+		Shutdown:  shutdownHandler,
+		Stream:    streamHandler,
 		Vesrion:   versionHandler,
 		WebSocket: webSocketHandler,
 	}
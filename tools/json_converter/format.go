@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// baseKeys are the keys written in their own column or before the other fields.
+var baseKeys = []string{"time", "level", "tasker_id", "caller", "msg", "stacktrace"}
+
+// writeArray writes the lines as an indented JSON array.
+func writeArray(w io.Writer, lines []line) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("[\n")
+	var indented bytes.Buffer
+	for i, l := range lines {
+		indented.Reset()
+		if err := json.Indent(&indented, l.Raw, "  ", "  "); err != nil {
+			return err
+		}
+		bw.WriteString("  ")
+		bw.Write(indented.Bytes())
+		if i < len(lines)-1 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n")
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+// writePretty writes one readable line per log, followed by its stack trace if any.
+func writePretty(w io.Writer, lines []line) error {
+	bw := bufio.NewWriter(w)
+	for _, l := range lines {
+		fmt.Fprintf(bw, "%s  %-5s  ", formatTime(l), strings.ToUpper(l.str("level")))
+		if id := l.str("tasker_id"); id != "" {
+			fmt.Fprintf(bw, "[%s] ", id)
+		}
+		bw.WriteString(l.str("msg"))
+		if extra := extraFields(l); extra != "" {
+			bw.WriteString("  ")
+			bw.WriteString(extra)
+		}
+		if caller := l.str("caller"); caller != "" {
+			fmt.Fprintf(bw, "  (%s)", caller)
+		}
+		bw.WriteString("\n")
+		if stack := l.str("stacktrace"); stack != "" {
+			for _, frame := range strings.Split(stack, "\n") {
+				fmt.Fprintf(bw, "    %s\n", frame)
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// writeCSV writes one row per log, the fields without a column are written as a JSON object.
+func writeCSV(w io.Writer, lines []line) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "level", "tasker_id", "caller", "msg", "fields"}); err != nil {
+		return err
+	}
+	for _, l := range lines {
+		record := []string{
+			l.str("time"),
+			l.str("level"),
+			l.str("tasker_id"),
+			l.str("caller"),
+			l.str("msg"),
+			extraFields(l),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// extraFields returns the fields of l which are not base keys as a JSON object, or an empty string if there is none.
+func extraFields(l line) string {
+	extra := make(map[string]interface{}, len(l.Fields))
+	for key, value := range l.Fields {
+		extra[key] = value
+	}
+	for _, key := range baseKeys {
+		delete(extra, key)
+	}
+	if len(extra) == 0 {
+		return ""
+	}
+	// encoding/json sorts map keys, so the output is stable.
+	data, err := json.Marshal(extra)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func formatTime(l line) string {
+	if l.Time.IsZero() {
+		return l.str("time")
+	}
+	return l.Time.Local().Format("2006-01-02 15:04:05.000")
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,75 +1,104 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 )
 
-func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: json_converter <input_file> <output_file>")
-		return
-	}
-
-	inputFile := os.Args[1]
-	outputFile := os.Args[2]
+// Output formats.
+const (
+	formatArray      = "array"
+	formatPretty     = "pretty"
+	formatCSV        = "csv"
+	formatSummary    = "summary"
+	formatSummaryCSV = "summary-csv"
+)
 
-	// Open input file.
-	file, err := os.Open(inputFile)
-	if err != nil {
-		fmt.Printf("Error opening input file: %v\n", err)
-		return
-	}
-	defer file.Close()
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: json_converter [flags] <input_file> <output_file>")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "The input file may be gzip-compressed (.gz). Use - as the output file to write to stdout.")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Formats:")
+	fmt.Fprintln(os.Stderr, "  array        an indented JSON array of the lines")
+	fmt.Fprintln(os.Stderr, "  pretty       one readable line per log")
+	fmt.Fprintln(os.Stderr, "  csv          one CSV row per log")
+	fmt.Fprintln(os.Stderr, "  summary      a readable report of every run: tasks, durations and failures")
+	fmt.Fprintln(os.Stderr, "  summary-csv  one CSV row per task of every run")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Flags:")
+	flag.PrintDefaults()
+}
 
-	var jsonArray []json.RawMessage
+func main() {
+	format := flag.String("format", formatArray, "Output format: array, pretty, csv, summary or summary-csv")
+	rotated := flag.Bool("rotated", false, "Also read the rotated backups of the input file, oldest first")
+	flag.Usage = usage
+	flag.Parse()
 
-	// Reads each line of the file and parses it into a JSON object.
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		jsonArray = append(jsonArray, json.RawMessage(line))
+	if flag.NArg() < 2 {
+		usage()
+		os.Exit(2)
 	}
+	inputFile := flag.Arg(0)
+	outputFile := flag.Arg(1)
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("Error reading input file: %v\n", err)
-		return
+	var write func(w io.Writer, lines []line) error
+	switch *format {
+	case formatArray:
+		write = writeArray
+	case formatPretty:
+		write = writePretty
+	case formatCSV:
+		write = writeCSV
+	case formatSummary:
+		write = writeSummary
+	case formatSummaryCSV:
+		write = writeSummaryCSV
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format %q.\n", *format)
+		os.Exit(2)
 	}
 
-	// Writes an array of JSON objects to the output file.
-	outputFileHandle, err := os.Create(outputFile)
+	inputs, err := inputFiles(inputFile, *rotated)
 	if err != nil {
-		fmt.Printf("Error creating output file: %v\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "Error listing input files: %v\n", err)
+		os.Exit(1)
 	}
-	defer outputFileHandle.Close()
 
-	// Manually build an array of JSON with indentation.
-	var buffer bytes.Buffer
-	buffer.WriteString("[\n")
-	for i, jsonObj := range jsonArray {
-		var indented bytes.Buffer
-		if err := json.Indent(&indented, jsonObj, "  ", "  "); err != nil {
-			fmt.Printf("Error indenting JSON: %v\n", err)
-			return
+	var (
+		lines  []line
+		report malformedReport
+	)
+	for _, input := range inputs {
+		fileLines, err := readFile(input, &report)
+		if err != nil {
+			// Keep the lines read before the error, e.g. of a truncated gzip file.
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", input, err)
 		}
-		buffer.WriteString("  ")
-		buffer.Write(indented.Bytes())
-		if i < len(jsonArray)-1 {
-			buffer.WriteString(",")
+		lines = append(lines, fileLines...)
+	}
+	report.print(os.Stderr)
+
+	var w io.Writer = os.Stdout
+	if outputFile != "-" {
+		file, err := os.Create(outputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+			os.Exit(1)
 		}
-		buffer.WriteString("\n")
+		defer file.Close()
+		w = file
 	}
-	buffer.WriteString("]")
 
-	_, err = outputFileHandle.Write(buffer.Bytes())
-	if err != nil {
-		fmt.Printf("Error writing JSON to output file: %v\n", err)
-		return
+	if err := write(w, lines); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output file: %v\n", err)
+		os.Exit(1)
 	}
 
-	fmt.Println("Successfully converted JSON objects to JSON array with indentation.")
+	if outputFile != "-" {
+		fmt.Printf("Successfully converted %d lines of %d files to %s.\n", len(lines), len(inputs), *format)
+	}
 }
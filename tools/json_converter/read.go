@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/logquery"
+)
+
+// maxReportedLines is the number of malformed lines listed in the report, the others are only counted.
+const maxReportedLines = 20
+
+// line is a decoded log line, Fields holds every field of the log.
+type line struct {
+	logquery.Entry
+	Fields map[string]interface{}
+}
+
+func (l line) str(key string) string {
+	s, _ := l.Fields[key].(string)
+	return s
+}
+
+func (l line) num(key string) float64 {
+	n, _ := l.Fields[key].(float64)
+	return n
+}
+
+type malformedLine struct {
+	file   string
+	number int
+	err    error
+}
+
+type malformedReport struct {
+	lines []malformedLine
+	count int
+}
+
+func (r *malformedReport) add(file string, number int, err error) {
+	r.count++
+	if len(r.lines) < maxReportedLines {
+		r.lines = append(r.lines, malformedLine{file: file, number: number, err: err})
+	}
+}
+
+func (r *malformedReport) print(w io.Writer) {
+	if r.count == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Skipped %d malformed lines:\n", r.count)
+	for _, l := range r.lines {
+		fmt.Fprintf(w, "  %s:%d: %v\n", l.file, l.number, l.err)
+	}
+	if r.count > len(r.lines) {
+		fmt.Fprintf(w, "  ... and %d more\n", r.count-len(r.lines))
+	}
+}
+
+// inputFiles returns path, preceded by its rotated backups if rotated is set.
+func inputFiles(path string, rotated bool) ([]string, error) {
+	if !rotated {
+		return []string{path}, nil
+	}
+	files, err := logquery.Files(path, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+	}
+	return files, nil
+}
+
+// readFile returns the lines of the file at path which are JSON objects, the other lines are added to report.
+func readFile(path string, report *malformedReport) ([]line, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var lines []line
+	reader := bufio.NewReader(r)
+	for number := 1; ; number++ {
+		data, err := reader.ReadBytes('\n')
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 {
+			l, parseErr := parseLine(data)
+			if parseErr != nil {
+				report.add(path, number, parseErr)
+			} else {
+				lines = append(lines, l)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return lines, nil
+		}
+		if err != nil {
+			return lines, err
+		}
+	}
+}
+
+func parseLine(data []byte) (line, error) {
+	entry, err := logquery.ParseLine(data)
+	if err != nil {
+		return line{}, err
+	}
+	l := line{Entry: entry}
+	if err := json.Unmarshal(entry.Raw, &l.Fields); err != nil {
+		return line{}, err
+	}
+	return l, nil
+}
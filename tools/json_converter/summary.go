@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/dongwlin/elf-aid-magic/internal/history"
+)
+
+// statusUnfinished is the status of a run whose end is not in the log.
+const statusUnfinished = "unfinished"
+
+type taskSummary struct {
+	Entry     string
+	StartedAt time.Time
+	EndedAt   time.Time
+	Status    string
+	Retried   bool
+}
+
+func (t *taskSummary) duration() time.Duration {
+	if t.EndedAt.IsZero() {
+		return 0
+	}
+	return t.EndedAt.Sub(t.StartedAt)
+}
+
+// runSummary is built from the lines of a run. A run whose finish line is missing,
+// e.g. because the process was killed, is unfinished and ends at its last line.
+type runSummary struct {
+	ID        string
+	TaskerID  string
+	Trigger   string
+	StartedAt time.Time
+	EndedAt   time.Time
+	Status    string
+	Error     string
+	Tasks     []*taskSummary
+}
+
+func (r *runSummary) duration() time.Duration {
+	return r.EndedAt.Sub(r.StartedAt)
+}
+
+func (r *runSummary) taskCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, task := range r.Tasks {
+		counts[task.Status]++
+	}
+	return counts
+}
+
+// lastRunningTask returns the last task with entry which has not ended yet.
+func (r *runSummary) lastRunningTask(entry string) *taskSummary {
+	for i := len(r.Tasks) - 1; i >= 0; i-- {
+		if r.Tasks[i].Entry == entry && r.Tasks[i].Status == history.StatusRunning {
+			return r.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// summarizeRuns returns the runs found in lines, in the order they started.
+func summarizeRuns(lines []line) []*runSummary {
+	var runs []*runSummary
+	byID := make(map[string]*runSummary)
+	for _, l := range lines {
+		id := l.str(history.LogKeyRunID)
+		if id == "" {
+			continue
+		}
+		run, exists := byID[id]
+		if !exists {
+			run = &runSummary{
+				ID:        id,
+				TaskerID:  l.str("tasker_id"),
+				StartedAt: l.Time,
+				Status:    statusUnfinished,
+			}
+			byID[id] = run
+			runs = append(runs, run)
+		}
+		if l.Time.After(run.EndedAt) {
+			run.EndedAt = l.Time
+		}
+
+		entry := l.str("entry")
+		switch l.str("msg") {
+		case history.LogMsgStartRun:
+			run.StartedAt = l.Time
+			run.Trigger = l.str("trigger")
+		case history.LogMsgRunTask:
+			run.Tasks = append(run.Tasks, &taskSummary{
+				Entry:     entry,
+				StartedAt: l.Time,
+				Status:    history.StatusRunning,
+			})
+		case history.LogMsgRetryTask:
+			if task := run.lastRunningTask(entry); task != nil {
+				task.Retried = true
+			}
+		case history.LogMsgTaskSuccess, history.LogMsgTaskFailed:
+			if task := run.lastRunningTask(entry); task != nil {
+				task.EndedAt = l.Time
+				task.Status = history.StatusSuccess
+				if l.str("msg") == history.LogMsgTaskFailed {
+					task.Status = history.StatusFailed
+				}
+			}
+		case history.LogMsgFinishRun:
+			run.Status = l.str("status")
+			run.Error = l.str("error")
+			if d := l.num("duration"); d > 0 {
+				// The duration is encoded in seconds.
+				run.StartedAt = l.Time.Add(-time.Duration(d * float64(time.Second)))
+			}
+		}
+	}
+	return runs
+}
+
+// writeSummary writes a readable report of every run.
+func writeSummary(w io.Writer, lines []line) error {
+	runs := summarizeRuns(lines)
+	bw := bufio.NewWriter(w)
+	if len(runs) == 0 {
+		bw.WriteString("No run found.\n")
+		return bw.Flush()
+	}
+
+	statuses := make(map[string]int)
+	for i, run := range runs {
+		if i > 0 {
+			bw.WriteString("\n")
+		}
+		statuses[run.Status]++
+
+		fmt.Fprintf(bw, "Run %s\n", run.ID)
+		fmt.Fprintf(bw, "  Tasker:   %s\n", run.TaskerID)
+		if run.Trigger != "" {
+			fmt.Fprintf(bw, "  Trigger:  %s\n", run.Trigger)
+		}
+		fmt.Fprintf(bw, "  Started:  %s\n", run.StartedAt.Local().Format(time.DateTime))
+		fmt.Fprintf(bw, "  Duration: %s\n", run.duration().Round(time.Second))
+		status := run.Status
+		if run.Error != "" {
+			status += " (" + run.Error + ")"
+		}
+		fmt.Fprintf(bw, "  Status:   %s\n", status)
+
+		counts := run.taskCounts()
+		fmt.Fprintf(bw, "  Tasks:    %d", len(run.Tasks))
+		for j, key := range sortedKeys(counts) {
+			sep := ", "
+			if j == 0 {
+				sep = " ("
+			}
+			fmt.Fprintf(bw, "%s%d %s", sep, counts[key], key)
+		}
+		if len(counts) > 0 {
+			bw.WriteString(")")
+		}
+		bw.WriteString("\n")
+
+		for j, task := range run.Tasks {
+			retried := ""
+			if task.Retried {
+				retried = "  retried"
+			}
+			fmt.Fprintf(bw, "    #%-3d %-8s %-30s %s%s\n", j+1, task.Status, task.Entry, task.duration().Round(time.Second), retried)
+		}
+	}
+
+	fmt.Fprintf(bw, "\nTotal: %d runs", len(runs))
+	for _, key := range sortedKeys(statuses) {
+		fmt.Fprintf(bw, ", %d %s", statuses[key], key)
+	}
+	bw.WriteString("\n")
+	return bw.Flush()
+}
+
+// writeSummaryCSV writes one row per task of every run, or a single row without task for a run without task.
+func writeSummaryCSV(w io.Writer, lines []line) error {
+	cw := csv.NewWriter(w)
+	header := []string{
+		"run_id", "tasker_id", "trigger", "run_status", "run_error", "run_started_at", "run_duration_seconds",
+		"task_index", "task_entry", "task_status", "task_duration_seconds", "task_retried",
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, run := range summarizeRuns(lines) {
+		record := []string{
+			run.ID,
+			run.TaskerID,
+			run.Trigger,
+			run.Status,
+			run.Error,
+			run.StartedAt.Format(time.RFC3339),
+			formatSeconds(run.duration()),
+		}
+		if len(run.Tasks) == 0 {
+			if err := cw.Write(append(record, "", "", "", "", "")); err != nil {
+				return err
+			}
+			continue
+		}
+		for i, task := range run.Tasks {
+			row := append(record[:len(record):len(record)],
+				strconv.Itoa(i+1),
+				task.Entry,
+				task.Status,
+				formatSeconds(task.duration()),
+				strconv.FormatBool(task.Retried),
+			)
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func parseLines(t *testing.T, data ...string) []line {
+	lines := make([]line, 0, len(data))
+	for _, d := range data {
+		l, err := parseLine([]byte(d))
+		require.NoError(t, err)
+		lines = append(lines, l)
+	}
+	return lines
+}
+
+func at(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func TestSummarizeRuns(t *testing.T) {
+	type task struct {
+		entry   string
+		status  string
+		retried bool
+	}
+	type run struct {
+		id        string
+		taskerID  string
+		trigger   string
+		status    string
+		err       string
+		startedAt time.Time
+		endedAt   time.Time
+		tasks     []task
+	}
+	tests := []struct {
+		name  string
+		lines []string
+		want  []run
+	}{
+		{
+			name: "finished",
+			lines: []string{
+				`{"level":"info","time":"2024-12-01T09:00:00.000Z","msg":"start run","tasker_id":"a","run id":"r1","trigger":"cli","tasks":2}`,
+				`{"level":"info","time":"2024-12-01T09:00:01.000Z","msg":"run task","tasker_id":"a","run id":"r1","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:30.000Z","msg":"success to complete the task","tasker_id":"a","run id":"r1","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:31.000Z","msg":"run task","tasker_id":"a","run id":"r1","entry":"Mail"}`,
+				`{"level":"error","time":"2024-12-01T09:01:00.000Z","msg":"failed to complete the task","tasker_id":"a","run id":"r1","entry":"Mail"}`,
+				`{"level":"info","time":"2024-12-01T09:01:00.000Z","msg":"finish run","tasker_id":"a","run id":"r1","status":"failed","error":"some tasks failed","duration":60}`,
+			},
+			want: []run{
+				{
+					id:        "r1",
+					taskerID:  "a",
+					trigger:   "cli",
+					status:    "failed",
+					err:       "some tasks failed",
+					startedAt: at("2024-12-01T09:00:00Z"),
+					endedAt:   at("2024-12-01T09:01:00Z"),
+					tasks: []task{
+						{entry: "Daily", status: "success"},
+						{entry: "Mail", status: "failed"},
+					},
+				},
+			},
+		},
+		{
+			name: "unfinished",
+			lines: []string{
+				`{"level":"info","time":"2024-12-01T09:00:00.000Z","msg":"start run","tasker_id":"a","run id":"r1","trigger":"websocket"}`,
+				`{"level":"info","time":"2024-12-01T09:00:01.000Z","msg":"run task","tasker_id":"a","run id":"r1","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:10.000Z","msg":"controller disconnected","tasker_id":"a"}`,
+				`{"level":"info","time":"2024-12-01T09:00:20.000Z","msg":"custom action","tasker_id":"a","run id":"r1"}`,
+			},
+			want: []run{
+				{
+					id:        "r1",
+					taskerID:  "a",
+					trigger:   "websocket",
+					status:    statusUnfinished,
+					startedAt: at("2024-12-01T09:00:00Z"),
+					endedAt:   at("2024-12-01T09:00:20Z"),
+					tasks: []task{
+						{entry: "Daily", status: "running"},
+					},
+				},
+			},
+		},
+		{
+			name: "retried",
+			lines: []string{
+				`{"level":"info","time":"2024-12-01T09:00:00.000Z","msg":"start run","tasker_id":"a","run id":"r1","trigger":"cli"}`,
+				`{"level":"info","time":"2024-12-01T09:00:01.000Z","msg":"start run","tasker_id":"b","run id":"r2","trigger":"cli"}`,
+				`{"level":"info","time":"2024-12-01T09:00:02.000Z","msg":"run task","tasker_id":"a","run id":"r1","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:03.000Z","msg":"run task","tasker_id":"b","run id":"r2","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:20.000Z","msg":"retry task after recovering the controller","tasker_id":"a","run id":"r1","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:40.000Z","msg":"success to complete the task","tasker_id":"a","run id":"r1","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:41.000Z","msg":"success to complete the task","tasker_id":"b","run id":"r2","entry":"Daily"}`,
+				`{"level":"info","time":"2024-12-01T09:00:42.000Z","msg":"finish run","tasker_id":"b","run id":"r2","status":"success","error":"","duration":41}`,
+				`{"level":"info","time":"2024-12-01T09:00:45.000Z","msg":"finish run","tasker_id":"a","run id":"r1","status":"success","error":"","duration":45}`,
+			},
+			want: []run{
+				{
+					id:        "r1",
+					taskerID:  "a",
+					trigger:   "cli",
+					status:    "success",
+					startedAt: at("2024-12-01T09:00:00Z"),
+					endedAt:   at("2024-12-01T09:00:45Z"),
+					tasks: []task{
+						{entry: "Daily", status: "success", retried: true},
+					},
+				},
+				{
+					id:        "r2",
+					taskerID:  "b",
+					trigger:   "cli",
+					status:    "success",
+					startedAt: at("2024-12-01T09:00:01Z"),
+					endedAt:   at("2024-12-01T09:00:42Z"),
+					tasks: []task{
+						{entry: "Daily", status: "success"},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []run
+			for _, r := range summarizeRuns(parseLines(t, tt.lines...)) {
+				summary := run{
+					id:        r.ID,
+					taskerID:  r.TaskerID,
+					trigger:   r.Trigger,
+					status:    r.Status,
+					err:       r.Error,
+					startedAt: r.StartedAt,
+					endedAt:   r.EndedAt,
+				}
+				for _, ts := range r.Tasks {
+					summary.tasks = append(summary.tasks, task{
+						entry:   ts.Entry,
+						status:  ts.Status,
+						retried: ts.Retried,
+					})
+				}
+				got = append(got, summary)
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}